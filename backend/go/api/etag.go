@@ -0,0 +1,66 @@
+/* Place: backend/go/api/etag.go */
+package api
+
+import (
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// Optimistic concurrency: resources with an rv ROWVERSION (users, posts, messages) send it as
+// a strong ETag. Writers echo it in If-Match and get 412 if someone else changed the row first.
+
+// etagFromRV formats a rowversion as a strong ETag.
+func etagFromRV(rv []byte) string {
+	return `"` + hex.EncodeToString(rv) + `"`
+}
+
+// setETag sets the ETag header when rv is known.
+func setETag(w http.ResponseWriter, rv []byte) {
+	if len(rv) > 0 {
+		w.Header().Set("ETag", etagFromRV(rv))
+	}
+}
+
+// ifMatchRV reads If-Match. rv is nil without the header or for "*" (any current version).
+// ok is false when the header can never match a rowversion (weak or malformed tags, or a
+// list), which callers answer with 412 like any other mismatch.
+func ifMatchRV(r *http.Request) (rv []byte, ok bool) {
+	h := strings.TrimSpace(r.Header.Get("If-Match"))
+	if h == "" || h == "*" {
+		return nil, true
+	}
+	if len(h) < 2 || h[0] != '"' || h[len(h)-1] != '"' {
+		return nil, false
+	}
+	rv, err := hex.DecodeString(h[1 : len(h)-1])
+	if err != nil || len(rv) == 0 {
+		return nil, false
+	}
+	return rv, true
+}
+
+// notModified reports whether If-None-Match already names the current version of rv; GET
+// handlers then answer 304 without a body.
+func notModified(r *http.Request, rv []byte) bool {
+	h := r.Header.Get("If-None-Match")
+	if h == "" || len(rv) == 0 {
+		return false
+	}
+	if strings.TrimSpace(h) == "*" {
+		return true
+	}
+	cur := etagFromRV(rv)
+	for _, tag := range strings.Split(h, ",") {
+		// If-None-Match uses weak comparison
+		if strings.TrimPrefix(strings.TrimSpace(tag), "W/") == cur {
+			return true
+		}
+	}
+	return false
+}
+
+// writePreconditionFailed is the 412 answer to a lost update.
+func writePreconditionFailed(w http.ResponseWriter) {
+	ErrorJSON(w, http.StatusPreconditionFailed, "resource was modified by another request; reload and try again")
+}
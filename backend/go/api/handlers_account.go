@@ -0,0 +1,79 @@
+/* Place: backend/go/api/handlers_account.go */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"gatherup/service"
+)
+
+// deletionPendingResp is returned instead of tokens when logging into an account that is
+// scheduled for deletion; posting restore_token to /auth/account/restore cancels the deletion.
+type deletionPendingResp struct {
+	DeletionPending     bool      `json:"deletion_pending"`
+	DeletionScheduledAt time.Time `json:"deletion_scheduled_at"`
+	RestoreToken        string    `json:"restore_token"`
+	ExpiresAt           time.Time `json:"expires_at"`
+}
+
+type deleteAccountReq struct {
+	Password string `json:"password"`
+}
+
+type restoreAccountReq struct {
+	RestoreToken string   `json:"restore_token"`
+	DeviceInfo   string   `json:"device_info,omitempty"`
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+}
+
+// DELETE /api/me
+func (h *AuthHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req deleteAccountReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Password == "" {
+		ErrorJSON(w, http.StatusBadRequest, "password required")
+		return
+	}
+	at, err := h.svc.RequestAccountDeletion(r.Context(), userID, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCredentials):
+			ErrorJSON(w, http.StatusUnauthorized, "invalid credentials")
+		case errors.Is(err, service.ErrDeletionAlreadyScheduled):
+			ErrorJSON(w, http.StatusConflict, err.Error())
+		default:
+			ErrorJSON(w, http.StatusInternalServerError, "account deletion failed")
+		}
+		return
+	}
+	JSON(w, http.StatusAccepted, map[string]time.Time{"deletion_scheduled_at": at})
+}
+
+// POST /auth/account/restore
+func (h *AuthHandler) RestoreAccount(w http.ResponseWriter, r *http.Request) {
+	var req restoreAccountReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.RestoreToken == "" {
+		ErrorJSON(w, http.StatusBadRequest, "restore_token required")
+		return
+	}
+	client := service.ClientInfo{
+		DeviceInfo: req.DeviceInfo,
+		IP:         clientIP(r),
+		Latitude:   req.Latitude,
+		Longitude:  req.Longitude,
+	}
+	access, accessExp, refreshRaw, _, err := h.svc.RestoreAccount(r.Context(), req.RestoreToken, client)
+	if errors.Is(err, service.ErrDeletionNotPending) {
+		ErrorJSON(w, http.StatusGone, err.Error())
+		return
+	}
+	writeLoginResult(w, access, accessExp, refreshRaw, err)
+}
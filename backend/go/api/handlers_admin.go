@@ -0,0 +1,101 @@
+/* Place: backend/go/api/handlers_admin.go */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"gatherup/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminHandler serves admin-only endpoints (mounted behind RequireRole(admin)).
+type AdminHandler struct {
+	authSvc *service.AuthService
+}
+
+func NewAdminHandler(authSvc *service.AuthService) *AdminHandler {
+	return &AdminHandler{authSvc: authSvc}
+}
+
+// GET /api/admin/users/{id}/roles
+func (h *AdminHandler) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := h.authSvc.GetRoles(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		ErrorJSON(w, http.StatusInternalServerError, "failed to fetch roles")
+		return
+	}
+	JSON(w, http.StatusOK, map[string][]string{"roles": roles})
+}
+
+// PUT /api/admin/users/{id}/roles/{role}
+func (h *AdminHandler) GrantRole(w http.ResponseWriter, r *http.Request) {
+	actorID, _ := FromContextUserID(r.Context())
+	err := h.authSvc.GrantRole(r.Context(), actorID, chi.URLParam(r, "id"), chi.URLParam(r, "role"))
+	writeRoleResult(w, err)
+}
+
+// DELETE /api/admin/users/{id}/roles/{role}
+func (h *AdminHandler) RevokeRole(w http.ResponseWriter, r *http.Request) {
+	actorID, _ := FromContextUserID(r.Context())
+	err := h.authSvc.RevokeRole(r.Context(), actorID, chi.URLParam(r, "id"), chi.URLParam(r, "role"))
+	writeRoleResult(w, err)
+}
+
+type impersonateReq struct {
+	// Reason (e.g. the support ticket) is stored in the audit log.
+	Reason string `json:"reason"`
+}
+
+// impersonateResp carries an access token only: impersonation tokens cannot be refreshed.
+type impersonateResp struct {
+	AccessToken string    `json:"access_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+	UserID      string    `json:"user_id"`
+}
+
+// POST /api/admin/users/{id}/impersonate
+func (h *AdminHandler) Impersonate(w http.ResponseWriter, r *http.Request) {
+	claims, ok := FromContextClaims(r.Context())
+	if !ok {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req impersonateReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+	targetID := chi.URLParam(r, "id")
+	token, exp, err := h.authSvc.Impersonate(r.Context(), claims, targetID, req.Reason, service.ClientInfo{IP: clientIP(r)})
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImpersonationReason):
+			ErrorJSON(w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, service.ErrImpersonationForbidden):
+			ErrorJSON(w, http.StatusForbidden, err.Error())
+		case errors.Is(err, service.ErrUserNotFound):
+			ErrorJSON(w, http.StatusNotFound, err.Error())
+		default:
+			ErrorJSON(w, http.StatusInternalServerError, "impersonation failed")
+		}
+		return
+	}
+	JSON(w, http.StatusOK, impersonateResp{AccessToken: token, ExpiresAt: exp, UserID: targetID})
+}
+
+func writeRoleResult(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRole):
+		ErrorJSON(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrRoleNotHeld):
+		ErrorJSON(w, http.StatusNotFound, err.Error())
+	case err != nil:
+		ErrorJSON(w, http.StatusInternalServerError, "role update failed")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
@@ -0,0 +1,195 @@
+/* Place: backend/go/api/handlers_apikeys.go */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"gatherup/models"
+	"gatherup/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// APIKeyHandler manages personal API keys and bot accounts.
+type APIKeyHandler struct {
+	svc *service.APIKeyService
+}
+
+func NewAPIKeyHandler(svc *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{svc: svc}
+}
+
+type createAPIKeyReq struct {
+	Name   string   `json:"name"`
+	Scopes []string `json:"scopes"`
+	// ExpiresInDays 0 means the server default.
+	ExpiresInDays int `json:"expires_in_days,omitempty"`
+}
+
+type createAPIKeyResp struct {
+	// APIKey is shown only once.
+	APIKey string         `json:"api_key"`
+	Key    *models.APIKey `json:"key"`
+}
+
+type createBotReq struct {
+	DisplayName string `json:"display_name"`
+	Username    string `json:"username,omitempty"`
+}
+
+// GET /api/me/api-keys
+func (h *APIKeyHandler) ListMine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	h.list(w, r, userID, userID)
+}
+
+// POST /api/me/api-keys
+func (h *APIKeyHandler) CreateMine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	h.create(w, r, userID, userID)
+}
+
+// DELETE /api/me/api-keys/{keyId}
+func (h *APIKeyHandler) RevokeMine(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	h.revoke(w, r, userID, userID)
+}
+
+// GET /api/bots
+func (h *APIKeyHandler) ListBots(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	bots, err := h.svc.ListBots(r.Context(), userID)
+	if err != nil {
+		ErrorJSON(w, http.StatusInternalServerError, "failed to fetch bots")
+		return
+	}
+	if bots == nil {
+		bots = []models.User{}
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"bots": bots})
+}
+
+// POST /api/bots
+func (h *APIKeyHandler) CreateBot(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req createBotReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	id, err := h.svc.CreateBot(r.Context(), userID, req.DisplayName, req.Username)
+	if err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+	JSON(w, http.StatusCreated, map[string]string{"id": id})
+}
+
+// GET /api/bots/{id}/api-keys
+func (h *APIKeyHandler) ListBotKeys(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	h.list(w, r, userID, chi.URLParam(r, "id"))
+}
+
+// POST /api/bots/{id}/api-keys
+func (h *APIKeyHandler) CreateBotKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	h.create(w, r, userID, chi.URLParam(r, "id"))
+}
+
+// DELETE /api/bots/{id}/api-keys/{keyId}
+func (h *APIKeyHandler) RevokeBotKey(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	h.revoke(w, r, userID, chi.URLParam(r, "id"))
+}
+
+func (h *APIKeyHandler) list(w http.ResponseWriter, r *http.Request, actorID, ownerID string) {
+	keys, err := h.svc.ListKeys(r.Context(), actorID, ownerID)
+	if err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"api_keys": keys})
+}
+
+func (h *APIKeyHandler) create(w http.ResponseWriter, r *http.Request, actorID, ownerID string) {
+	var req createAPIKeyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if req.ExpiresInDays < 0 {
+		ErrorJSON(w, http.StatusBadRequest, "expires_in_days must be positive")
+		return
+	}
+	ttl := time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	raw, key, err := h.svc.CreateKey(r.Context(), actorID, ownerID, req.Name, req.Scopes, ttl)
+	if err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+	JSON(w, http.StatusCreated, createAPIKeyResp{APIKey: raw, Key: key})
+}
+
+func (h *APIKeyHandler) revoke(w http.ResponseWriter, r *http.Request, actorID, ownerID string) {
+	if err := h.svc.RevokeKey(r.Context(), actorID, ownerID, chi.URLParam(r, "keyId")); err != nil {
+		writeAPIKeyError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeAPIKeyError(w http.ResponseWriter, err error) {
+	var fieldErr *service.FieldError
+	switch {
+	case errors.As(err, &fieldErr):
+		JSON(w, http.StatusBadRequest, map[string]string{"error": fieldErr.Error(), "field": fieldErr.Field})
+	case errors.Is(err, service.ErrAPIKeyName), errors.Is(err, service.ErrInvalidScope), errors.Is(err, service.ErrAPIKeyTTL),
+		errors.Is(err, service.ErrBotDisplayName):
+		ErrorJSON(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrAPIKeyNotFound), errors.Is(err, service.ErrBotNotFound):
+		ErrorJSON(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrAPIKeyLimit), errors.Is(err, service.ErrUsernameTaken):
+		ErrorJSON(w, http.StatusConflict, err.Error())
+	default:
+		ErrorJSON(w, http.StatusInternalServerError, "api key request failed")
+	}
+}
@@ -4,8 +4,12 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"log"
+	"math"
 	"net/http"
 	"regexp"
+	"strconv"
 	"time"
 
 	"gatherup/service"
@@ -20,9 +24,13 @@ type registerReq struct {
 }
 
 type loginReq struct {
-	MobileNumber string `json:"mobile_number"`
-	Password     string `json:"password"`
-	DeviceInfo   string `json:"device_info,omitempty"`
+	// Identifier is a mobile number, verified email or username; MobileNumber is kept for older clients.
+	Identifier   string   `json:"identifier,omitempty"`
+	MobileNumber string   `json:"mobile_number"`
+	Password     string   `json:"password"`
+	DeviceInfo   string   `json:"device_info,omitempty"`
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
 }
 
 type tokenResp struct {
@@ -62,6 +70,10 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	id, err := h.svc.Register(ctx, req.MobileNumber, req.Password)
+	if errors.Is(err, service.ErrWeakPassword) {
+		ErrorJSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	if err != nil {
 		// log the error server-side (assumes a logger available); return safe message
 		ErrorJSON(w, http.StatusBadRequest, "register failed")
@@ -74,25 +86,77 @@ func (h *AuthHandler) Register(w http.ResponseWriter, r *http.Request) {
 func (h *AuthHandler) Login(w http.ResponseWriter, r *http.Request) {
 	var req loginReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid body", http.StatusBadRequest)
+		ErrorJSON(w, http.StatusBadRequest, "invalid body")
 		return
 	}
-	if req.MobileNumber == "" || req.Password == "" {
-		http.Error(w, "mobile_number and password required", http.StatusBadRequest)
+	identifier := req.Identifier
+	if identifier == "" {
+		if req.MobileNumber != "" && !mobileRe.MatchString(req.MobileNumber) {
+			ErrorJSON(w, http.StatusBadRequest, "invalid mobile_number format")
+			return
+		}
+		identifier = req.MobileNumber
+	}
+	if identifier == "" || req.Password == "" {
+		ErrorJSON(w, http.StatusBadRequest, "identifier and password required")
 		return
 	}
-	if !mobileRe.MatchString(req.MobileNumber) {
-		http.Error(w, "invalid mobile_number format", http.StatusBadRequest)
+	client := service.ClientInfo{
+		DeviceInfo: req.DeviceInfo,
+		IP:         clientIP(r),
+		Latitude:   req.Latitude,
+		Longitude:  req.Longitude,
+	}
+	access, accessExp, refreshRaw, _, err := h.svc.Login(r.Context(), identifier, req.Password, client)
+	writeLoginResult(w, access, accessExp, refreshRaw, err)
+}
+
+// writeLoginResult writes the tokens of a successful login, the MFA challenge when a second
+// factor is needed, or the mapped error. Shared by every login method.
+func writeLoginResult(w http.ResponseWriter, access string, accessExp time.Time, refreshRaw string, err error) {
+	var mfa *service.MFARequiredError
+	if errors.As(err, &mfa) {
+		JSON(w, http.StatusOK, mfaChallengeResp{MFARequired: true, MFAToken: mfa.Token, ExpiresAt: mfa.ExpiresAt})
+		return
+	}
+	var pending *service.DeletionPendingError
+	if errors.As(err, &pending) {
+		JSON(w, http.StatusConflict, deletionPendingResp{
+			DeletionPending:     true,
+			DeletionScheduledAt: pending.ScheduledAt,
+			RestoreToken:        pending.RestoreToken,
+			ExpiresAt:           pending.ExpiresAt,
+		})
 		return
 	}
-	access, accessExp, refreshRaw, _, err := h.svc.Login(r.Context(), req.MobileNumber, req.Password, req.DeviceInfo)
 	if err != nil {
-		http.Error(w, "login failed: "+err.Error(), http.StatusUnauthorized)
+		writeLoginError(w, err)
 		return
 	}
-	resp := tokenResp{AccessToken: access, RefreshToken: refreshRaw, ExpiresAt: accessExp}
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(resp)
+	JSON(w, http.StatusOK, tokenResp{AccessToken: access, RefreshToken: refreshRaw, ExpiresAt: accessExp})
+}
+
+// writeLoginError maps login failures to one uniform response per class, never echoing
+// internal errors or whether the account exists.
+func writeLoginError(w http.ResponseWriter, err error) {
+	var locked *service.LockedError
+	switch {
+	case errors.As(err, &locked):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		ErrorJSON(w, http.StatusTooManyRequests, "too many failed attempts, try again later")
+	case errors.Is(err, service.ErrPasswordlessDisabled):
+		ErrorJSON(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrMobileNotVerified):
+		ErrorJSON(w, http.StatusForbidden, "mobile number not verified")
+	case errors.Is(err, service.ErrInvalidCredentials):
+		ErrorJSON(w, http.StatusUnauthorized, "invalid credentials")
+	case errors.Is(err, service.ErrMFAInvalid), errors.Is(err, service.ErrTOTPNotEnabled):
+		ErrorJSON(w, http.StatusUnauthorized, "invalid two-factor code")
+	case errors.Is(err, service.ErrMFAChallengeInvalid):
+		ErrorJSON(w, http.StatusUnauthorized, "mfa challenge expired, log in again")
+	default:
+		ErrorJSON(w, http.StatusInternalServerError, "login failed")
+	}
 }
 
 type refreshReq struct {
@@ -102,18 +166,62 @@ type refreshReq struct {
 func (h *AuthHandler) Refresh(w http.ResponseWriter, r *http.Request) {
 	var req refreshReq
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "invalid body", http.StatusBadRequest)
+		ErrorJSON(w, http.StatusBadRequest, "invalid body")
 		return
 	}
 	if req.RefreshToken == "" {
-		http.Error(w, "refresh_token required", http.StatusBadRequest)
+		ErrorJSON(w, http.StatusBadRequest, "refresh_token required")
+		return
+	}
+	newAccess, accessExp, newRefreshRaw, _, err := h.svc.Refresh(r.Context(), req.RefreshToken, clientIP(r))
+	if err != nil {
+		writeRefreshError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, tokenResp{AccessToken: newAccess, RefreshToken: newRefreshRaw, ExpiresAt: accessExp})
+}
+
+// writeRefreshError answers every rejected refresh token alike, so clients can't tell unknown,
+// revoked and reused tokens apart; other failures are logged, not echoed.
+func writeRefreshError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrRefreshTokenNotFound), errors.Is(err, service.ErrRefreshTokenReused):
+		ErrorJSON(w, http.StatusUnauthorized, "invalid refresh token")
+	default:
+		log.Printf("Refresh: %v", err)
+		ErrorJSON(w, http.StatusInternalServerError, "refresh failed")
+	}
+}
+
+// POST /auth/logout
+func (h *AuthHandler) Logout(w http.ResponseWriter, r *http.Request) {
+	var req refreshReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if req.RefreshToken == "" {
+		ErrorJSON(w, http.StatusBadRequest, "refresh_token required")
+		return
+	}
+	if err := h.svc.Logout(r.Context(), req.RefreshToken); err != nil {
+		ErrorJSON(w, http.StatusInternalServerError, "logout failed")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /auth/logout-all (authenticated)
+func (h *AuthHandler) LogoutAll(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
-	newAccess, accessExp, newRefreshRaw, _, err := h.svc.Refresh(r.Context(), req.RefreshToken)
+	n, err := h.svc.LogoutAll(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "refresh failed: "+err.Error(), http.StatusUnauthorized)
+		ErrorJSON(w, http.StatusInternalServerError, "logout failed")
 		return
 	}
-	w.Header().Set("Content-Type", "application/json")
-	_ = json.NewEncoder(w).Encode(tokenResp{AccessToken: newAccess, RefreshToken: newRefreshRaw, ExpiresAt: accessExp})
+	JSON(w, http.StatusOK, map[string]int64{"revoked": n})
 }
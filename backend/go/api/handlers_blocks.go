@@ -0,0 +1,91 @@
+/* Place: backend/go/api/handlers_blocks.go */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gatherup/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// BlockHandler serves the caller's block list.
+type BlockHandler struct {
+	svc *service.BlockService
+}
+
+func NewBlockHandler(svc *service.BlockService) *BlockHandler {
+	return &BlockHandler{svc: svc}
+}
+
+type blockReq struct {
+	Reason string `json:"reason,omitempty"`
+}
+
+// GET /api/blocks?cursor=&limit=
+func (h *BlockHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	limit, ok := queryLimit(w, r)
+	if !ok {
+		return
+	}
+	page, err := h.svc.List(r.Context(), userID, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		writeBlockError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, page)
+}
+
+// POST /api/blocks/{userId}
+func (h *BlockHandler) Block(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req blockReq
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			ErrorJSON(w, http.StatusBadRequest, "invalid request")
+			return
+		}
+	}
+	if err := h.svc.Block(r.Context(), userID, chi.URLParam(r, "userId"), req.Reason); err != nil {
+		writeBlockError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /api/blocks/{userId}
+func (h *BlockHandler) Unblock(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.svc.Unblock(r.Context(), userID, chi.URLParam(r, "userId")); err != nil {
+		writeBlockError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeBlockError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrBlockSelf), errors.Is(err, service.ErrBlockReason),
+		errors.Is(err, service.ErrInvalidCursor):
+		ErrorJSON(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrNotBlocked):
+		ErrorJSON(w, http.StatusNotFound, err.Error())
+	default:
+		ErrorJSON(w, http.StatusInternalServerError, "block request failed")
+	}
+}
@@ -0,0 +1,168 @@
+/* Place: backend/go/api/handlers_contacts.go */
+package api
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gatherup/models"
+	"gatherup/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ContactHandler serves contact requests and contact lists.
+type ContactHandler struct {
+	svc *service.ContactService
+}
+
+func NewContactHandler(svc *service.ContactService) *ContactHandler {
+	return &ContactHandler{svc: svc}
+}
+
+// GET /api/contacts?status=accepted|incoming|outgoing&cursor=&limit=
+func (h *ContactHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	q := r.URL.Query()
+	limit, ok := queryLimit(w, r)
+	if !ok {
+		return
+	}
+	page, err := h.svc.List(r.Context(), userID, q.Get("status"), q.Get("cursor"), limit)
+	if err != nil {
+		writeContactError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, page)
+}
+
+// POST /api/contacts/requests/{userId}
+func (h *ContactHandler) SendRequest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	status, err := h.svc.SendRequest(r.Context(), userID, chi.URLParam(r, "userId"))
+	if err != nil {
+		writeContactError(w, err)
+		return
+	}
+	code := http.StatusCreated
+	if status != models.ContactPending {
+		code = http.StatusOK
+	}
+	JSON(w, code, map[string]string{"status": status})
+}
+
+// DELETE /api/contacts/requests/{userId}
+func (h *ContactHandler) CancelRequest(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.svc.Cancel(r.Context(), userID, chi.URLParam(r, "userId")); err != nil {
+		writeContactError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/contacts/requests/{userId}/accept
+func (h *ContactHandler) Accept(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.svc.Accept(r.Context(), userID, chi.URLParam(r, "userId")); err != nil {
+		writeContactError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]string{"status": models.ContactAccepted})
+}
+
+// POST /api/contacts/requests/{userId}/decline
+func (h *ContactHandler) Decline(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.svc.Decline(r.Context(), userID, chi.URLParam(r, "userId")); err != nil {
+		writeContactError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// DELETE /api/contacts/{userId}
+func (h *ContactHandler) Unfriend(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	if err := h.svc.Unfriend(r.Context(), userID, chi.URLParam(r, "userId")); err != nil {
+		writeContactError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/contacts/{userId}/mutual?limit=
+func (h *ContactHandler) Mutual(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	limit, ok := queryLimit(w, r)
+	if !ok {
+		return
+	}
+	users, err := h.svc.Mutual(r.Context(), userID, chi.URLParam(r, "userId"), limit)
+	if err != nil {
+		writeContactError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"users": users})
+}
+
+// queryLimit parses the optional ?limit= (0 when absent); on a bad value it writes 400 and
+// returns false.
+func queryLimit(w http.ResponseWriter, r *http.Request) (int, bool) {
+	v := r.URL.Query().Get("limit")
+	if v == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 1 {
+		ErrorJSON(w, http.StatusBadRequest, "limit must be a positive integer")
+		return 0, false
+	}
+	return n, true
+}
+
+func writeContactError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrContactSelf), errors.Is(err, service.ErrContactStatus),
+		errors.Is(err, service.ErrInvalidCursor):
+		ErrorJSON(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrAlreadyContacts), errors.Is(err, service.ErrContactRequestExists):
+		ErrorJSON(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrTooManyContactRequests):
+		ErrorJSON(w, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, service.ErrUserNotFound), errors.Is(err, service.ErrContactRequestNotFound),
+		errors.Is(err, service.ErrNotContacts):
+		ErrorJSON(w, http.StatusNotFound, err.Error())
+	default:
+		ErrorJSON(w, http.StatusInternalServerError, "contact request failed")
+	}
+}
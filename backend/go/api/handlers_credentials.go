@@ -0,0 +1,108 @@
+/* Place: backend/go/api/handlers_credentials.go */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"gatherup/models"
+	"gatherup/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type emailReq struct {
+	Email string `json:"email"`
+	Code  string `json:"code,omitempty"`
+}
+
+// GET /api/me/credentials
+func (h *AuthHandler) ListCredentials(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	creds, err := h.svc.ListCredentials(r.Context(), userID)
+	if err != nil {
+		ErrorJSON(w, http.StatusInternalServerError, "failed to fetch credentials")
+		return
+	}
+	if creds == nil {
+		creds = []models.Credential{}
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"credentials": creds})
+}
+
+// POST /api/me/credentials/email
+func (h *AuthHandler) AddEmail(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req emailReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" {
+		ErrorJSON(w, http.StatusBadRequest, "email required")
+		return
+	}
+	if err := h.svc.RequestEmailLink(r.Context(), userID, req.Email); err != nil {
+		writeCredentialError(w, err)
+		return
+	}
+	JSON(w, http.StatusAccepted, map[string]string{"status": "sent"})
+}
+
+// POST /api/me/credentials/email/verify
+func (h *AuthHandler) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req emailReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Email == "" || req.Code == "" {
+		ErrorJSON(w, http.StatusBadRequest, "email and code required")
+		return
+	}
+	if err := h.svc.VerifyEmailLink(r.Context(), userID, req.Email, req.Code); err != nil {
+		writeCredentialError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]bool{"is_email_verified": true})
+}
+
+// DELETE /api/me/credentials/{id}
+func (h *AuthHandler) RemoveCredential(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	id, err := strconv.ParseInt(chi.URLParam(r, "id"), 10, 64)
+	if err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid credential id")
+		return
+	}
+	if err := h.svc.RemoveCredential(r.Context(), userID, id); err != nil {
+		writeCredentialError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeCredentialError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidEmail):
+		ErrorJSON(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrCredentialNotFound):
+		ErrorJSON(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrEmailAlreadyLinked), errors.Is(err, service.ErrCredentialTaken),
+		errors.Is(err, service.ErrPasswordCredential), errors.Is(err, service.ErrCredentialManaged):
+		ErrorJSON(w, http.StatusConflict, err.Error())
+	default:
+		writeOTPError(w, err)
+	}
+}
@@ -0,0 +1,71 @@
+/* Place: backend/go/api/handlers_discovery.go */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"gatherup/service"
+)
+
+// DiscoveryHandler serves phone-book contact discovery.
+type DiscoveryHandler struct {
+	svc *service.DiscoveryService
+}
+
+func NewDiscoveryHandler(svc *service.DiscoveryService) *DiscoveryHandler {
+	return &DiscoveryHandler{svc: svc}
+}
+
+type discoverReq struct {
+	Hashes []string `json:"hashes"`
+}
+
+// GET /api/contacts/discover (hashing parameters)
+func (h *DiscoveryHandler) Info(w http.ResponseWriter, r *http.Request) {
+	info, err := h.svc.Info()
+	if err != nil {
+		writeDiscoveryError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, info)
+}
+
+// POST /api/contacts/discover
+func (h *DiscoveryHandler) Discover(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req discoverReq
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid request")
+		return
+	}
+	matches, err := h.svc.Discover(r.Context(), userID, req.Hashes)
+	if err != nil {
+		writeDiscoveryError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"matches": matches})
+}
+
+func writeDiscoveryError(w http.ResponseWriter, err error) {
+	var batchErr *service.DiscoveryBatchError
+	var limitErr *service.DiscoveryLimitError
+	switch {
+	case errors.As(err, &batchErr), errors.Is(err, service.ErrDiscoveryHash):
+		ErrorJSON(w, http.StatusBadRequest, err.Error())
+	case errors.As(err, &limitErr):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))))
+		ErrorJSON(w, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, service.ErrDiscoveryDisabled):
+		ErrorJSON(w, http.StatusNotFound, err.Error())
+	default:
+		ErrorJSON(w, http.StatusInternalServerError, "contact discovery failed")
+	}
+}
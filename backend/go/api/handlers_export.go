@@ -0,0 +1,106 @@
+/* Place: backend/go/api/handlers_export.go */
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"gatherup/models"
+	"gatherup/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ExportHandler serves "download my data" requests.
+type ExportHandler struct {
+	svc *service.ExportService
+}
+
+func NewExportHandler(svc *service.ExportService) *ExportHandler {
+	return &ExportHandler{svc: svc}
+}
+
+type exportResp struct {
+	*models.DataExport
+	// DownloadURL is set once the archive is ready.
+	DownloadURL string `json:"download_url,omitempty"`
+}
+
+func newExportResp(e *models.DataExport) exportResp {
+	resp := exportResp{DataExport: e}
+	if e.Status == models.ExportCompleted {
+		resp.DownloadURL = "/api/me/export/" + e.ID + "/download"
+	}
+	return resp
+}
+
+// POST /api/me/export
+func (h *ExportHandler) Request(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	e, err := h.svc.RequestExport(r.Context(), userID)
+	if err != nil {
+		writeExportError(w, err)
+		return
+	}
+	w.Header().Set("Location", "/api/me/export/"+e.ID)
+	JSON(w, http.StatusAccepted, newExportResp(e))
+}
+
+// GET /api/me/export/{id}
+func (h *ExportHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	e, err := h.svc.GetExport(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeExportError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, newExportResp(e))
+}
+
+// GET /api/me/export/{id}/download
+func (h *ExportHandler) Download(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	e, rc, err := h.svc.OpenExport(r.Context(), userID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeExportError(w, err)
+		return
+	}
+	defer rc.Close()
+
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="gatherup-export-%s.zip"`, e.CreatedAt.Format("20060102")))
+	w.Header().Set("Cache-Control", "no-store")
+	if e.SizeBytes != nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(*e.SizeBytes, 10))
+	}
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, rc)
+}
+
+func writeExportError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrExportNotFound):
+		ErrorJSON(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrExportNotReady):
+		ErrorJSON(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrExportExpired):
+		ErrorJSON(w, http.StatusGone, err.Error())
+	default:
+		ErrorJSON(w, http.StatusInternalServerError, "export request failed")
+	}
+}
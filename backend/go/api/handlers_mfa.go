@@ -0,0 +1,160 @@
+/* Place: backend/go/api/handlers_mfa.go */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"gatherup/service"
+)
+
+// mfaChallengeResp replaces tokenResp when the account needs a second factor.
+type mfaChallengeResp struct {
+	MFARequired bool      `json:"mfa_required"`
+	MFAToken    string    `json:"mfa_token"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+type loginMFAReq struct {
+	MFAToken     string   `json:"mfa_token"`
+	Code         string   `json:"code,omitempty"`
+	RecoveryCode string   `json:"recovery_code,omitempty"`
+	DeviceInfo   string   `json:"device_info,omitempty"`
+	Latitude     *float64 `json:"latitude,omitempty"`
+	Longitude    *float64 `json:"longitude,omitempty"`
+}
+
+type totpCodeReq struct {
+	Code         string `json:"code"`
+	RecoveryCode string `json:"recovery_code,omitempty"`
+}
+
+// POST /auth/login/mfa
+func (h *AuthHandler) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	var req loginMFAReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if req.MFAToken == "" || (req.Code == "" && req.RecoveryCode == "") {
+		ErrorJSON(w, http.StatusBadRequest, "mfa_token and code or recovery_code required")
+		return
+	}
+	client := service.ClientInfo{
+		DeviceInfo: req.DeviceInfo,
+		IP:         clientIP(r),
+		Latitude:   req.Latitude,
+		Longitude:  req.Longitude,
+	}
+	access, accessExp, refreshRaw, _, err := h.svc.LoginMFA(r.Context(), req.MFAToken, req.Code, req.RecoveryCode, client)
+	writeLoginResult(w, access, accessExp, refreshRaw, err)
+}
+
+// GET /api/me/2fa
+func (h *AuthHandler) TOTPStatus(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	enabled, remaining, err := h.svc.TOTPStatus(r.Context(), userID)
+	if err != nil {
+		ErrorJSON(w, http.StatusInternalServerError, "failed to fetch two-factor status")
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"totp_enabled":             enabled,
+		"recovery_codes_remaining": remaining,
+	})
+}
+
+// POST /api/me/2fa/totp
+func (h *AuthHandler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	enrollment, err := h.svc.EnrollTOTP(r.Context(), userID)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, enrollment)
+}
+
+// POST /api/me/2fa/totp/confirm
+func (h *AuthHandler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req totpCodeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		ErrorJSON(w, http.StatusBadRequest, "code required")
+		return
+	}
+	codes, err := h.svc.ConfirmTOTP(r.Context(), userID, req.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"recovery_codes": codes})
+}
+
+// DELETE /api/me/2fa/totp
+func (h *AuthHandler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req totpCodeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Code == "" && req.RecoveryCode == "") {
+		ErrorJSON(w, http.StatusBadRequest, "code or recovery_code required")
+		return
+	}
+	if err := h.svc.DisableTOTP(r.Context(), userID, req.Code, req.RecoveryCode); err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// POST /api/me/2fa/recovery-codes
+func (h *AuthHandler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req totpCodeReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Code == "" {
+		ErrorJSON(w, http.StatusBadRequest, "code required")
+		return
+	}
+	codes, err := h.svc.RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+	if err != nil {
+		writeMFAError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"recovery_codes": codes})
+}
+
+func writeMFAError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrMFAInvalid):
+		ErrorJSON(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrTOTPAlreadyEnabled):
+		ErrorJSON(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrTOTPNotEnrolled), errors.Is(err, service.ErrTOTPNotEnabled):
+		ErrorJSON(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrUserNotFound):
+		ErrorJSON(w, http.StatusNotFound, err.Error())
+	default:
+		ErrorJSON(w, http.StatusInternalServerError, "two-factor request failed")
+	}
+}
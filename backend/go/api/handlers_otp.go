@@ -0,0 +1,118 @@
+/* Place: backend/go/api/handlers_otp.go */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gatherup/service"
+)
+
+type otpRequestReq struct {
+	MobileNumber string `json:"mobile_number"`
+}
+
+type otpVerifyReq struct {
+	MobileNumber string `json:"mobile_number"`
+	Code         string `json:"code"`
+}
+
+// POST /auth/otp/request
+func (h *AuthHandler) RequestOTP(w http.ResponseWriter, r *http.Request) {
+	var req otpRequestReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if !mobileRe.MatchString(req.MobileNumber) {
+		ErrorJSON(w, http.StatusBadRequest, "invalid mobile_number format")
+		return
+	}
+	if err := h.svc.RequestMobileVerification(r.Context(), req.MobileNumber); err != nil {
+		writeOTPError(w, err)
+		return
+	}
+	// same response whether or not the number is registered
+	JSON(w, http.StatusAccepted, map[string]string{"status": "sent"})
+}
+
+// POST /auth/otp/verify
+func (h *AuthHandler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
+	var req otpVerifyReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if !mobileRe.MatchString(req.MobileNumber) || req.Code == "" {
+		ErrorJSON(w, http.StatusBadRequest, "mobile_number and code required")
+		return
+	}
+	if err := h.svc.VerifyMobile(r.Context(), req.MobileNumber, req.Code); err != nil {
+		writeOTPError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, map[string]bool{"is_mobile_verified": true})
+}
+
+type loginOTPReq struct {
+	Identifier string   `json:"identifier"`
+	Code       string   `json:"code,omitempty"`
+	DeviceInfo string   `json:"device_info,omitempty"`
+	Latitude   *float64 `json:"latitude,omitempty"`
+	Longitude  *float64 `json:"longitude,omitempty"`
+}
+
+// POST /auth/login/otp
+func (h *AuthHandler) RequestLoginOTP(w http.ResponseWriter, r *http.Request) {
+	var req loginOTPReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if req.Identifier == "" {
+		ErrorJSON(w, http.StatusBadRequest, "identifier required")
+		return
+	}
+	if err := h.svc.RequestLoginOTP(r.Context(), req.Identifier, clientIP(r)); err != nil {
+		writeLoginError(w, err)
+		return
+	}
+	// same response whether or not the identifier is registered
+	JSON(w, http.StatusAccepted, map[string]string{"status": "sent"})
+}
+
+// POST /auth/login/otp/verify
+func (h *AuthHandler) LoginOTP(w http.ResponseWriter, r *http.Request) {
+	var req loginOTPReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if req.Identifier == "" || req.Code == "" {
+		ErrorJSON(w, http.StatusBadRequest, "identifier and code required")
+		return
+	}
+	client := service.ClientInfo{
+		DeviceInfo: req.DeviceInfo,
+		IP:         clientIP(r),
+		Latitude:   req.Latitude,
+		Longitude:  req.Longitude,
+	}
+	access, accessExp, refreshRaw, _, err := h.svc.LoginWithOTP(r.Context(), req.Identifier, req.Code, client)
+	writeLoginResult(w, access, accessExp, refreshRaw, err)
+}
+
+// writeOTPError maps OTP service errors to HTTP responses.
+func writeOTPError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, service.ErrOTPRateLimited):
+		ErrorJSON(w, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, service.ErrOTPInvalid), errors.Is(err, service.ErrOTPExpired), errors.Is(err, service.ErrOTPTooManyAttempts):
+		// One answer for every rejection: expired or locked codes only exist for registered
+		// destinations, so distinguishing them would reveal which ones have accounts.
+		ErrorJSON(w, http.StatusBadRequest, "invalid or expired code; request a new one if this keeps failing")
+	default:
+		ErrorJSON(w, http.StatusInternalServerError, "otp failed")
+	}
+}
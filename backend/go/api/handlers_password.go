@@ -0,0 +1,95 @@
+/* Place: backend/go/api/handlers_password.go */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gatherup/service"
+)
+
+type changePasswordReq struct {
+	CurrentPassword string `json:"current_password"`
+	NewPassword     string `json:"new_password"`
+}
+
+type forgotPasswordReq struct {
+	MobileNumber string `json:"mobile_number"`
+}
+
+type resetPasswordReq struct {
+	MobileNumber string `json:"mobile_number"`
+	Code         string `json:"code"`
+	NewPassword  string `json:"new_password"`
+}
+
+// POST /api/me/password
+func (h *AuthHandler) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req changePasswordReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		ErrorJSON(w, http.StatusBadRequest, "current_password and new_password required")
+		return
+	}
+	err := h.svc.ChangePassword(r.Context(), userID, req.CurrentPassword, req.NewPassword)
+	switch {
+	case errors.Is(err, service.ErrWeakPassword):
+		ErrorJSON(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrInvalidCredentials):
+		ErrorJSON(w, http.StatusForbidden, "current password is incorrect")
+	case err != nil:
+		ErrorJSON(w, http.StatusInternalServerError, "change password failed")
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// POST /auth/password/forgot
+func (h *AuthHandler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	var req forgotPasswordReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if !mobileRe.MatchString(req.MobileNumber) {
+		ErrorJSON(w, http.StatusBadRequest, "invalid mobile_number format")
+		return
+	}
+	if err := h.svc.ForgotPassword(r.Context(), req.MobileNumber); err != nil {
+		writeOTPError(w, err)
+		return
+	}
+	JSON(w, http.StatusAccepted, map[string]string{"status": "sent"})
+}
+
+// POST /auth/password/reset
+func (h *AuthHandler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	var req resetPasswordReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid body")
+		return
+	}
+	if !mobileRe.MatchString(req.MobileNumber) || req.Code == "" || req.NewPassword == "" {
+		ErrorJSON(w, http.StatusBadRequest, "mobile_number, code and new_password required")
+		return
+	}
+	err := h.svc.ResetPassword(r.Context(), req.MobileNumber, req.Code, req.NewPassword)
+	if errors.Is(err, service.ErrWeakPassword) {
+		ErrorJSON(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		writeOTPError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
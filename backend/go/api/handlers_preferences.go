@@ -0,0 +1,66 @@
+/* Place: backend/go/api/handlers_preferences.go */
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"gatherup/service"
+)
+
+// PreferencesHandler serves the caller's notification, locale and theme preferences.
+type PreferencesHandler struct {
+	svc *service.PreferencesService
+}
+
+func NewPreferencesHandler(svc *service.PreferencesService) *PreferencesHandler {
+	return &PreferencesHandler{svc: svc}
+}
+
+// GET /api/me/preferences
+func (h *PreferencesHandler) Get(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	p, err := h.svc.Get(r.Context(), userID)
+	if err != nil {
+		writePreferencesError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, p)
+}
+
+// PATCH /api/me/preferences
+func (h *PreferencesHandler) Update(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	var req service.PreferencesUpdate
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid request: only notify_on_message, notify_on_like, notify_on_comment, timezone, lang and theme can be updated")
+		return
+	}
+	p, err := h.svc.Update(r.Context(), userID, req)
+	if err != nil {
+		writePreferencesError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, p)
+}
+
+func writePreferencesError(w http.ResponseWriter, err error) {
+	var fieldErr *service.FieldError
+	switch {
+	case errors.As(err, &fieldErr):
+		JSON(w, http.StatusBadRequest, map[string]string{"error": fieldErr.Error(), "field": fieldErr.Field})
+	default:
+		ErrorJSON(w, http.StatusInternalServerError, "preferences request failed")
+	}
+}
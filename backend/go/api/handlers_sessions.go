@@ -0,0 +1,68 @@
+/* Place: backend/go/api/handlers_sessions.go */
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"gatherup/models"
+	"gatherup/service"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SessionHandler exposes the caller's login sessions (devices).
+type SessionHandler struct {
+	svc *service.AuthService
+}
+
+func NewSessionHandler(svc *service.AuthService) *SessionHandler {
+	return &SessionHandler{svc: svc}
+}
+
+type sessionResp struct {
+	models.UserSession
+	Current bool `json:"current"`
+}
+
+// GET /api/sessions
+func (h *SessionHandler) List(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	sessions, err := h.svc.ListSessions(r.Context(), userID)
+	if err != nil {
+		ErrorJSON(w, http.StatusInternalServerError, "failed to fetch sessions")
+		return
+	}
+	currentID := ""
+	if c, ok := FromContextClaims(r.Context()); ok {
+		currentID = c.SessionID
+	}
+	resp := make([]sessionResp, 0, len(sessions))
+	for _, s := range sessions {
+		resp = append(resp, sessionResp{UserSession: s, Current: s.ID == currentID})
+	}
+	JSON(w, http.StatusOK, map[string]interface{}{"sessions": resp})
+}
+
+// DELETE /api/sessions/{id}
+func (h *SessionHandler) Revoke(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	err := h.svc.RevokeSession(r.Context(), userID, chi.URLParam(r, "id"))
+	if errors.Is(err, service.ErrSessionNotFound) {
+		ErrorJSON(w, http.StatusNotFound, "session not found")
+		return
+	}
+	if err != nil {
+		ErrorJSON(w, http.StatusInternalServerError, "failed to revoke session")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
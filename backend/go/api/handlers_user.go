@@ -3,42 +3,252 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
-	"gatherup/repository"
+	"gatherup/geo"
+	"gatherup/service"
+
+	"github.com/go-chi/chi/v5"
 )
 
 type UserHandler struct {
-	repo *repository.UserRepo
+	svc *service.ProfileService
 }
 
-func NewUserHandler(repo *repository.UserRepo) *UserHandler {
-	return &UserHandler{repo: repo}
+func NewUserHandler(svc *service.ProfileService) *UserHandler {
+	return &UserHandler{svc: svc}
 }
 
 // GET /api/me
 func (h *UserHandler) Me(w http.ResponseWriter, r *http.Request) {
 	userID, ok := FromContextUserID(r.Context())
 	if !ok || userID == "" {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	u, err := h.svc.GetProfile(r.Context(), userID)
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	setETag(w, u.Rv)
+	if notModified(r, u.Rv) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	JSON(w, http.StatusOK, u)
+}
+
+// PATCH /api/me (honors If-Match; username, a login identifier, cannot be changed while impersonating)
+func (h *UserHandler) UpdateMe(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	ifMatch, valid := ifMatchRV(r)
+	if !valid {
+		writePreconditionFailed(w)
+		return
+	}
+	var req service.ProfileUpdate
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		ErrorJSON(w, http.StatusBadRequest, "invalid request: only display_name, username, avatar_url, bio, gender and date_of_birth can be updated")
+		return
+	}
+	if _, impersonated := FromContextImpersonator(r.Context()); impersonated && req.Username.Set {
+		ErrorJSON(w, http.StatusForbidden, "not allowed while impersonating")
+		return
+	}
+	u, err := h.svc.UpdateProfile(r.Context(), userID, req, ifMatch)
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	setETag(w, u.Rv)
+	JSON(w, http.StatusOK, u)
+}
+
+type updateLocationReq struct {
+	Latitude  *float64 `json:"latitude"`
+	Longitude *float64 `json:"longitude"`
+	// Precision is exact, street, neighborhood (default) or city.
+	Precision string `json:"precision,omitempty"`
+}
+
+// PUT /api/me/location (honors If-Match)
+func (h *UserHandler) UpdateLocation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	ifMatch, valid := ifMatchRV(r)
+	if !valid {
+		writePreconditionFailed(w)
+		return
+	}
+	var req updateLocationReq
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Latitude == nil || req.Longitude == nil {
+		ErrorJSON(w, http.StatusBadRequest, "latitude and longitude required")
+		return
+	}
+	u, err := h.svc.UpdateLocation(r.Context(), userID, geo.Point{Lat: *req.Latitude, Lon: *req.Longitude}, req.Precision, ifMatch)
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	setETag(w, u.Rv)
+	JSON(w, http.StatusOK, map[string]interface{}{
+		"latitude":            u.Latitude,
+		"longitude":           u.Longitude,
+		"location_updated_at": u.LocationUpdatedAt,
+	})
+}
+
+// DELETE /api/me/location (honors If-Match)
+func (h *UserHandler) ClearLocation(w http.ResponseWriter, r *http.Request) {
+	userID, ok := FromContextUserID(r.Context())
+	if !ok || userID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
-	u, err := h.repo.GetByID(r.Context(), userID)
+	ifMatch, valid := ifMatchRV(r)
+	if !valid {
+		writePreconditionFailed(w)
+		return
+	}
+	if err := h.svc.ClearLocation(r.Context(), userID, ifMatch); err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /api/users/nearby?radius_km=&game_type=&limit=
+func (h *UserHandler) Nearby(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := FromContextUserID(r.Context())
+	if !ok || viewerID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	q := r.URL.Query()
+	radius := 0.0
+	if v := q.Get("radius_km"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			ErrorJSON(w, http.StatusBadRequest, service.ErrNearbyRadius.Error())
+			return
+		}
+		radius = f
+	}
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			ErrorJSON(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	users, err := h.svc.FindNearby(r.Context(), viewerID, radius, q.Get("game_type"), limit)
 	if err != nil {
-		http.Error(w, "failed to fetch user", http.StatusInternalServerError)
+		writeProfileError(w, err)
 		return
 	}
-	if u == nil {
-		http.Error(w, "user not found", http.StatusNotFound)
+	JSON(w, http.StatusOK, map[string]interface{}{"users": users})
+}
+
+// GET /api/users/search?q=&cursor=&limit=
+func (h *UserHandler) Search(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := FromContextUserID(r.Context())
+	if !ok || viewerID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
 		return
 	}
-	resp := map[string]interface{}{
-		"id":            u.ID,
-		"mobile_number": u.MobileNumber,
-		"email":         u.Email,
-		"username":      u.Username,
-		"created_at":    u.CreatedAt,
+	q := r.URL.Query()
+	limit := 0
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			ErrorJSON(w, http.StatusBadRequest, "limit must be a positive integer")
+			return
+		}
+		limit = n
+	}
+	page, err := h.svc.SearchUsers(r.Context(), viewerID, q.Get("q"), q.Get("cursor"), limit)
+	if err != nil {
+		var limitErr *service.DiscoveryLimitError
+		switch {
+		case errors.Is(err, service.ErrSearchQuery), errors.Is(err, service.ErrInvalidCursor):
+			ErrorJSON(w, http.StatusBadRequest, err.Error())
+		case errors.As(err, &limitErr):
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(limitErr.RetryAfter.Seconds()))))
+			ErrorJSON(w, http.StatusTooManyRequests, err.Error())
+		default:
+			ErrorJSON(w, http.StatusInternalServerError, "search failed")
+		}
+		return
+	}
+	JSON(w, http.StatusOK, page)
+}
+
+// GET /api/users/{id}
+func (h *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := FromContextUserID(r.Context())
+	if !ok || viewerID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	p, err := h.svc.GetPublicProfile(r.Context(), viewerID, chi.URLParam(r, "id"))
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, p)
+}
+
+// GET /api/users/by-username/{username}
+func (h *UserHandler) GetUserByUsername(w http.ResponseWriter, r *http.Request) {
+	viewerID, ok := FromContextUserID(r.Context())
+	if !ok || viewerID == "" {
+		ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+		return
+	}
+	p, err := h.svc.GetPublicProfileByUsername(r.Context(), viewerID, chi.URLParam(r, "username"))
+	if err != nil {
+		writeProfileError(w, err)
+		return
+	}
+	JSON(w, http.StatusOK, p)
+}
+
+func writeProfileError(w http.ResponseWriter, err error) {
+	var fieldErr *service.FieldError
+	var cooldown *service.UsernameCooldownError
+	switch {
+	case errors.As(err, &fieldErr):
+		JSON(w, http.StatusBadRequest, map[string]string{"error": fieldErr.Error(), "field": fieldErr.Field})
+	case errors.As(err, &cooldown):
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(cooldown.RetryAfter.Seconds()))))
+		ErrorJSON(w, http.StatusTooManyRequests, err.Error())
+	case errors.Is(err, service.ErrUsernameTaken):
+		JSON(w, http.StatusConflict, map[string]string{"error": "that username is already taken, try another one", "field": "username"})
+	case errors.Is(err, geo.ErrInvalidCoordinates), errors.Is(err, service.ErrLocationPrecision),
+		errors.Is(err, service.ErrNearbyRadius), errors.Is(err, service.ErrUnknownGameType):
+		ErrorJSON(w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, service.ErrLocationNotSet):
+		ErrorJSON(w, http.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrPreconditionFailed):
+		writePreconditionFailed(w)
+	case errors.Is(err, service.ErrUserNotFound):
+		ErrorJSON(w, http.StatusNotFound, err.Error())
+	default:
+		ErrorJSON(w, http.StatusInternalServerError, "profile request failed")
 	}
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
 }
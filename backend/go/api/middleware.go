@@ -3,41 +3,124 @@ package api
 
 import (
 	"context"
+	"log"
+	"net"
 	"net/http"
 	"strings"
+
+	"gatherup/auth"
+	"gatherup/service"
+
+	"github.com/go-chi/chi/v5/middleware"
 )
 
 // ctx key for user id
 type ctxKey string
 
 const ctxUserIDKey ctxKey = "user_id"
+const ctxClaimsKey ctxKey = "claims"
+const ctxImpersonatorKey ctxKey = "impersonator_id"
 
-// WithAuth returns middleware that uses verify function to validate token and set user id in context
-func WithAuth(verify func(token string) (string, error)) func(http.Handler) http.Handler {
+// VerifyFunc validates a bearer credential and returns its claims.
+type VerifyFunc func(ctx context.Context, token string) (*auth.Claims, error)
+
+// WithAuth returns middleware that uses verify function to validate token and set user id and claims in context.
+// The credential comes from "Authorization: Bearer ..." or, for API keys, the X-API-Key header;
+// whether API keys are accepted is up to verify (see AcceptAPIKeys).
+func WithAuth(verify VerifyFunc) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			authH := r.Header.Get("Authorization")
-			if authH == "" {
-				http.Error(w, "authorization required", http.StatusUnauthorized)
-				return
+			token := r.Header.Get("X-API-Key")
+			if token == "" {
+				authH := r.Header.Get("Authorization")
+				if authH == "" {
+					http.Error(w, "authorization required", http.StatusUnauthorized)
+					return
+				}
+				parts := strings.SplitN(authH, " ", 2)
+				if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
+					http.Error(w, "invalid authorization header", http.StatusUnauthorized)
+					return
+				}
+				token = parts[1]
 			}
-			parts := strings.SplitN(authH, " ", 2)
-			if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-				http.Error(w, "invalid authorization header", http.StatusUnauthorized)
-				return
-			}
-			token := parts[1]
-			userID, err := verify(token)
+			claims, err := verify(r.Context(), token)
 			if err != nil {
-				http.Error(w, "invalid token: "+err.Error(), http.StatusUnauthorized)
+				// the cause (expired, revoked, unknown key, lookup failure) stays in the server log
+				log.Printf("WithAuth: %s %s: %v", r.Method, r.URL.Path, err)
+				ErrorJSON(w, http.StatusUnauthorized, "invalid token")
 				return
 			}
-			ctx := context.WithValue(r.Context(), ctxUserIDKey, userID)
+			ctx := context.WithValue(r.Context(), ctxUserIDKey, claims.UserID)
+			ctx = context.WithValue(ctx, ctxClaimsKey, claims)
+			if actorID := claims.ImpersonatorID(); actorID != "" {
+				ctx = context.WithValue(ctx, ctxImpersonatorKey, actorID)
+			}
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
 	}
 }
 
+// AcceptAPIKeys returns a VerifyFunc that checks API keys with verifyKey and everything else
+// with verify. Routes behind it should declare what keys may do with RequireScope.
+func AcceptAPIKeys(verify, verifyKey VerifyFunc) VerifyFunc {
+	return func(ctx context.Context, token string) (*auth.Claims, error) {
+		if auth.IsAPIKey(token) {
+			return verifyKey(ctx, token)
+		}
+		return verify(ctx, token)
+	}
+}
+
+// FromContextImpersonator returns the admin behind an impersonated request.
+func FromContextImpersonator(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(ctxImpersonatorKey).(string)
+	return id, ok && id != ""
+}
+
+// AuditImpersonation returns middleware that reports every impersonated request, with its
+// response status, to record. Must be mounted after WithAuth.
+func AuditImpersonation(record func(context.Context, service.ImpersonatedRequest)) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			actorID, ok := FromContextImpersonator(r.Context())
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r)
+			userID, _ := FromContextUserID(r.Context())
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			// the entry must be written even if the client went away
+			record(context.WithoutCancel(r.Context()), service.ImpersonatedRequest{
+				ActorID:   actorID,
+				UserID:    userID,
+				Method:    r.Method,
+				Path:      r.URL.Path,
+				Status:    status,
+				IP:        clientIP(r),
+				UserAgent: r.UserAgent(),
+			})
+		})
+	}
+}
+
+// DenyImpersonation returns middleware that rejects impersonated requests; it guards routes
+// that change credentials, sessions or the account itself. Must be mounted after WithAuth.
+func DenyImpersonation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := FromContextImpersonator(r.Context()); ok {
+			ErrorJSON(w, http.StatusForbidden, "not allowed while impersonating")
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
 // FromContextUserID extracts user id from request context
 func FromContextUserID(ctx context.Context) (string, bool) {
 	v := ctx.Value(ctxUserIDKey)
@@ -47,3 +130,65 @@ func FromContextUserID(ctx context.Context) (string, bool) {
 	id, ok := v.(string)
 	return id, ok
 }
+
+// RequireRole returns middleware that allows the request only if the authenticated
+// token carries at least one of roles. Must be mounted after WithAuth.
+func RequireRole(roles ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := FromContextClaims(r.Context())
+			if !ok {
+				ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			if !claims.HasRole(roles...) {
+				ErrorJSON(w, http.StatusForbidden, "forbidden")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// RequireScope returns middleware that lets API key requests through only if the key has scope.
+// Access token requests are not scoped and pass. Must be mounted after WithAuth.
+func RequireScope(scope string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := FromContextClaims(r.Context())
+			if !ok {
+				ErrorJSON(w, http.StatusUnauthorized, "unauthorized")
+				return
+			}
+			if !claims.HasScope(scope) {
+				ErrorJSON(w, http.StatusForbidden, "api key lacks scope "+scope)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// FromContextRoles returns the roles of the authenticated token
+func FromContextRoles(ctx context.Context) []string {
+	if c, ok := FromContextClaims(ctx); ok {
+		return c.Roles
+	}
+	return nil
+}
+
+// FromContextClaims extracts verified token claims from request context
+func FromContextClaims(ctx context.Context) (*auth.Claims, bool) {
+	c, ok := ctx.Value(ctxClaimsKey).(*auth.Claims)
+	return c, ok && c != nil
+}
+
+// clientIP returns the peer address without port. Proxy headers are not trusted here;
+// terminate TLS in front and set RemoteAddr there if a real client IP is needed.
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -5,34 +5,119 @@ import (
 	"net/http"
 
 	"gatherup/auth"
-	"gatherup/repository"
 	"gatherup/service"
 
 	"github.com/go-chi/chi/v5"
 )
 
-// WireRouter wires handlers and middleware; pass in repo, jwt manager and auth service
-func WireRouter(repo *repository.UserRepo, jwtMgr *auth.JWTManager, authSvc *service.AuthService) http.Handler {
+// WireRouter wires handlers and middleware; pass in jwt manager and services
+func WireRouter(jwtMgr *auth.JWTManager, authSvc *service.AuthService, apiKeySvc *service.APIKeyService, exportSvc *service.ExportService, profileSvc *service.ProfileService, contactSvc *service.ContactService, blockSvc *service.BlockService, discoverySvc *service.DiscoveryService, prefsSvc *service.PreferencesService) http.Handler {
 	r := chi.NewRouter()
 
-	verifyFn := func(token string) (string, error) {
-		claims, err := jwtMgr.Verify(token)
-		if err != nil {
-			return "", err
-		}
-		return claims.UserID, nil
-	}
+	verifyFn := authSvc.VerifyAccessToken
+	// routes that scripts and bots may call; each declares the API key scope it needs
+	verifyKeyFn := AcceptAPIKeys(verifyFn, apiKeySvc.Verify)
 
 	authHandler := NewAuthHandler(authSvc)
-	userHandler := NewUserHandler(repo)
+	userHandler := NewUserHandler(profileSvc)
+	sessionHandler := NewSessionHandler(authSvc)
+	adminHandler := NewAdminHandler(authSvc)
+	apiKeyHandler := NewAPIKeyHandler(apiKeySvc)
+	exportHandler := NewExportHandler(exportSvc)
+	contactHandler := NewContactHandler(contactSvc)
+	blockHandler := NewBlockHandler(blockSvc)
+	discoveryHandler := NewDiscoveryHandler(discoverySvc)
+	prefsHandler := NewPreferencesHandler(prefsSvc)
 
 	r.Post("/auth/register", authHandler.Register)
 	r.Post("/auth/login", authHandler.Login)
+	r.Post("/auth/login/mfa", authHandler.LoginMFA)
+	r.Post("/auth/login/otp", authHandler.RequestLoginOTP)
+	r.Post("/auth/login/otp/verify", authHandler.LoginOTP)
+	r.Post("/auth/account/restore", authHandler.RestoreAccount)
 	r.Post("/auth/refresh", authHandler.Refresh)
+	r.Post("/auth/logout", authHandler.Logout)
+	r.Post("/auth/otp/request", authHandler.RequestOTP)
+	r.Post("/auth/otp/verify", authHandler.VerifyOTP)
+	r.Post("/auth/password/forgot", authHandler.ForgotPassword)
+	r.Post("/auth/password/reset", authHandler.ResetPassword)
 
 	r.Group(func(r chi.Router) {
-		r.Use(WithAuth(verifyFn))
-		r.Get("/api/me", userHandler.Me)
+		r.Use(WithAuth(verifyFn), AuditImpersonation(authSvc.RecordImpersonatedRequest))
+		r.Patch("/api/me", userHandler.UpdateMe)
+		r.Get("/api/me/preferences", prefsHandler.Get)
+		r.Patch("/api/me/preferences", prefsHandler.Update)
+		r.Put("/api/me/location", userHandler.UpdateLocation)
+		r.Delete("/api/me/location", userHandler.ClearLocation)
+		r.Get("/api/users/search", userHandler.Search)
+		r.Get("/api/users/nearby", userHandler.Nearby)
+		r.Get("/api/users/{id}", userHandler.GetUser)
+		r.Get("/api/users/by-username/{username}", userHandler.GetUserByUsername)
+		r.Get("/api/contacts", contactHandler.List)
+		r.Post("/api/contacts/requests/{userId}", contactHandler.SendRequest)
+		r.Delete("/api/contacts/requests/{userId}", contactHandler.CancelRequest)
+		r.Post("/api/contacts/requests/{userId}/accept", contactHandler.Accept)
+		r.Post("/api/contacts/requests/{userId}/decline", contactHandler.Decline)
+		r.Delete("/api/contacts/{userId}", contactHandler.Unfriend)
+		r.Get("/api/contacts/{userId}/mutual", contactHandler.Mutual)
+		r.Get("/api/contacts/discover", discoveryHandler.Info)
+		r.Post("/api/contacts/discover", discoveryHandler.Discover)
+		r.Get("/api/blocks", blockHandler.List)
+		r.Post("/api/blocks/{userId}", blockHandler.Block)
+		r.Delete("/api/blocks/{userId}", blockHandler.Unblock)
+		r.Get("/api/me/credentials", authHandler.ListCredentials)
+		r.Get("/api/me/2fa", authHandler.TOTPStatus)
+		r.Get("/api/sessions", sessionHandler.List)
+		r.Get("/api/me/api-keys", apiKeyHandler.ListMine)
+		r.Get("/api/me/export/{id}", exportHandler.Get)
+
+		// credentials, sessions, keys, exports and the account itself stay with its owner
+		r.Group(func(r chi.Router) {
+			r.Use(DenyImpersonation)
+			r.Post("/auth/logout-all", authHandler.LogoutAll)
+			r.Delete("/api/me", authHandler.DeleteAccount)
+			r.Post("/api/me/password", authHandler.ChangePassword)
+			r.Post("/api/me/credentials/email", authHandler.AddEmail)
+			r.Post("/api/me/credentials/email/verify", authHandler.VerifyEmail)
+			r.Delete("/api/me/credentials/{id}", authHandler.RemoveCredential)
+			r.Post("/api/me/2fa/totp", authHandler.EnrollTOTP)
+			r.Post("/api/me/2fa/totp/confirm", authHandler.ConfirmTOTP)
+			r.Delete("/api/me/2fa/totp", authHandler.DisableTOTP)
+			r.Post("/api/me/2fa/recovery-codes", authHandler.RegenerateRecoveryCodes)
+			r.Delete("/api/sessions/{id}", sessionHandler.Revoke)
+			r.Post("/api/me/api-keys", apiKeyHandler.CreateMine)
+			r.Delete("/api/me/api-keys/{keyId}", apiKeyHandler.RevokeMine)
+			r.Post("/api/me/export", exportHandler.Request)
+			r.Get("/api/me/export/{id}/download", exportHandler.Download)
+		})
+
+		r.Route("/api/bots", func(r chi.Router) {
+			r.Use(RequireRole(auth.RoleTournamentOrganizer, auth.RoleAdmin))
+			r.Get("/", apiKeyHandler.ListBots)
+			r.Get("/{id}/api-keys", apiKeyHandler.ListBotKeys)
+			r.With(DenyImpersonation).Post("/", apiKeyHandler.CreateBot)
+			r.With(DenyImpersonation).Post("/{id}/api-keys", apiKeyHandler.CreateBotKey)
+			r.With(DenyImpersonation).Delete("/{id}/api-keys/{keyId}", apiKeyHandler.RevokeBotKey)
+		})
+
+		r.Route("/api/admin", func(r chi.Router) {
+			r.Use(RequireRole(auth.RoleAdmin))
+			r.Get("/users/{id}/roles", adminHandler.ListRoles)
+			r.Put("/users/{id}/roles/{role}", adminHandler.GrantRole)
+			r.Delete("/users/{id}/roles/{role}", adminHandler.RevokeRole)
+			r.Post("/users/{id}/impersonate", adminHandler.Impersonate)
+		})
+	})
+
+	r.Group(func(r chi.Router) {
+		r.Use(WithAuth(verifyKeyFn), AuditImpersonation(authSvc.RecordImpersonatedRequest))
+		r.With(RequireScope(auth.ScopeProfileRead)).Get("/api/me", userHandler.Me)
+	})
+
+	// public keys for services that verify access tokens without the signing secret
+	r.Get("/.well-known/jwks.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "public, max-age=300")
+		JSON(w, http.StatusOK, jwtMgr.JWKS())
 	})
 
 	r.Get("/health", func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,29 @@
+/* Place: backend/go/auth/apikey.go */
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strings"
+)
+
+// APIKeyPrefix starts every API key so it can be told apart from a JWT (and found by secret scanners).
+const APIKeyPrefix = "gu_"
+
+// apiKeyDisplayLen is how much of a key is stored in clear to identify it in listings.
+const apiKeyDisplayLen = 10
+
+// GenerateAPIKey returns a new raw key, its hash (HashRefreshToken scheme) and display prefix.
+func GenerateAPIKey() (raw, hash, prefix string, err error) {
+	b := make([]byte, 32)
+	if _, err = rand.Read(b); err != nil {
+		return "", "", "", err
+	}
+	raw = APIKeyPrefix + base64.RawURLEncoding.EncodeToString(b)
+	return raw, HashRefreshToken(raw), raw[:apiKeyDisplayLen], nil
+}
+
+// IsAPIKey reports whether a presented bearer credential is an API key rather than a JWT.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
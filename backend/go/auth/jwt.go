@@ -3,64 +3,222 @@ package auth
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// SigningKey is one JWT key identified by KID. Keys without a private part (or secret)
+// are verify-only, which lets a retired key keep validating tokens until they expire.
+type SigningKey struct {
+	KID    string
+	Method jwt.SigningMethod
+	// Private signs tokens (*rsa.PrivateKey or ed25519.PrivateKey); nil for verify-only keys.
+	Private interface{}
+	// Public verifies tokens (*rsa.PublicKey or ed25519.PublicKey).
+	Public interface{}
+	// Secret is used instead of Private/Public for HS256 keys.
+	Secret []byte
+}
+
+func (k *SigningKey) canSign() bool {
+	return k.Private != nil || len(k.Secret) > 0
+}
+
+func (k *SigningKey) verifyKey() interface{} {
+	if len(k.Secret) > 0 {
+		return k.Secret
+	}
+	return k.Public
+}
+
 type JWTManager struct {
-	secret []byte
+	keys   map[string]*SigningKey
+	active *SigningKey
 	ttl    time.Duration
 }
 
 type Claims struct {
-	UserID string   `json:"sub"`
-	Roles  []string `json:"roles,omitempty"`
+	UserID string `json:"sub"`
+	// SessionID links the access token to its refresh token session so logout can revoke it.
+	SessionID string   `json:"sid,omitempty"`
+	Roles     []string `json:"roles,omitempty"`
+	// Purpose marks single-purpose tokens (e.g. PurposeMFA); such tokens are never access tokens.
+	Purpose string `json:"purpose,omitempty"`
+	// Scopes and APIKeyID are set when the request authenticated with an API key instead of a JWT.
+	Scopes   []string `json:"scopes,omitempty"`
+	APIKeyID string   `json:"-"`
+	// Act is set on impersonation tokens: the token acts as UserID on behalf of Act.Subject.
+	Act *Actor `json:"act,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// Actor is the RFC 8693 "act" claim naming who is really behind a delegated token.
+type Actor struct {
+	Subject string `json:"sub"`
+}
+
+// ImpersonatorID returns the admin behind an impersonation token, or "".
+func (c *Claims) ImpersonatorID() string {
+	if c.Act == nil {
+		return ""
+	}
+	return c.Act.Subject
+}
+
+// Purposes of single-purpose tokens: PurposeMFA is the challenge between password and second
+// factor, PurposeRestore lets a login during the deletion grace period cancel the deletion.
+const (
+	PurposeMFA     = "mfa"
+	PurposeRestore = "restore_account"
+)
+
+var ErrTokenPurpose = errors.New("token not valid for this use")
+
+// NewHS256Key returns an HMAC key; kid may be empty (legacy tokens carry no kid header).
+func NewHS256Key(kid, secret string) *SigningKey {
+	return &SigningKey{KID: kid, Method: jwt.SigningMethodHS256, Secret: []byte(secret)}
+}
+
 // NewJWTManager creates JWT manager with HMAC secret and TTL.
 func NewJWTManager(secret string, ttl time.Duration) *JWTManager {
+	k := NewHS256Key("", secret)
 	return &JWTManager{
-		secret: []byte(secret),
+		keys:   map[string]*SigningKey{"": k},
+		active: k,
 		ttl:    ttl,
 	}
 }
 
-// Generate creates a signed JWT string and returns expiry.
-func (m *JWTManager) Generate(userID string, roles []string) (string, time.Time, error) {
+// NewJWTManagerWithKeys creates a JWT manager that signs with the key activeKID and
+// verifies with any of keys (matched by the token's "kid" header).
+func NewJWTManagerWithKeys(keys []*SigningKey, activeKID string, ttl time.Duration) (*JWTManager, error) {
+	m := &JWTManager{keys: make(map[string]*SigningKey, len(keys)), ttl: ttl}
+	for _, k := range keys {
+		if _, dup := m.keys[k.KID]; dup {
+			return nil, fmt.Errorf("duplicate jwt kid %q", k.KID)
+		}
+		m.keys[k.KID] = k
+	}
+	active, ok := m.keys[activeKID]
+	if !ok {
+		return nil, fmt.Errorf("active jwt kid %q not configured", activeKID)
+	}
+	if !active.canSign() {
+		return nil, fmt.Errorf("active jwt kid %q has no private key", activeKID)
+	}
+	m.active = active
+	return m, nil
+}
+
+// Generate creates a signed JWT string for the given session and returns expiry.
+func (m *JWTManager) Generate(userID, sessionID string, roles []string) (string, time.Time, error) {
 	now := time.Now().UTC()
 	exp := now.Add(m.ttl)
+	claims := Claims{
+		UserID:    userID,
+		SessionID: sessionID,
+		Roles:     roles,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(exp),
+			Subject:   userID,
+		},
+	}
+	ss, err := m.sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return ss, exp, nil
+}
+
+// GeneratePurpose creates a short-lived token usable only for purpose (see VerifyPurpose).
+func (m *JWTManager) GeneratePurpose(userID, purpose string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now().UTC()
+	exp := now.Add(ttl)
+	claims := Claims{
+		UserID:  userID,
+		Purpose: purpose,
+		RegisteredClaims: jwt.RegisteredClaims{
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(exp),
+			Subject:   userID,
+		},
+	}
+	ss, err := m.sign(claims)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return ss, exp, nil
+}
+
+// GenerateImpersonation creates a short-lived access token for userID carrying actorID in the
+// "act" claim. It has no session and no refresh token, so it cannot be refreshed.
+func (m *JWTManager) GenerateImpersonation(userID, actorID string, roles []string, ttl time.Duration) (string, time.Time, error) {
+	now := time.Now().UTC()
+	exp := now.Add(ttl)
 	claims := Claims{
 		UserID: userID,
 		Roles:  roles,
+		Act:    &Actor{Subject: actorID},
 		RegisteredClaims: jwt.RegisteredClaims{
 			IssuedAt:  jwt.NewNumericDate(now),
 			ExpiresAt: jwt.NewNumericDate(exp),
 			Subject:   userID,
 		},
 	}
-	tok := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	ss, err := tok.SignedString(m.secret)
+	ss, err := m.sign(claims)
 	if err != nil {
 		return "", time.Time{}, err
 	}
 	return ss, exp, nil
 }
 
-// Verify parses and validates a token and returns claims.
+// sign signs claims with the active key, setting the "kid" header when the key has one.
+func (m *JWTManager) sign(claims jwt.Claims) (string, error) {
+	tok := jwt.NewWithClaims(m.active.Method, claims)
+	if m.active.KID != "" {
+		tok.Header["kid"] = m.active.KID
+	}
+	if len(m.active.Secret) > 0 {
+		return tok.SignedString(m.active.Secret)
+	}
+	return tok.SignedString(m.active.Private)
+}
+
+// Verify parses and validates an access token and returns claims.
 func (m *JWTManager) Verify(tokenStr string) (*Claims, error) {
-	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return m.secret, nil
-	})
+	return m.VerifyPurpose(tokenStr, "")
+}
+
+// VerifyPurpose is Verify for tokens from GeneratePurpose; purpose must match exactly.
+func (m *JWTManager) VerifyPurpose(tokenStr, purpose string) (*Claims, error) {
+	token, err := jwt.ParseWithClaims(tokenStr, &Claims{}, m.keyFunc)
 	if err != nil {
 		return nil, err
 	}
 	if claims, ok := token.Claims.(*Claims); ok && token.Valid {
+		if claims.Purpose != purpose {
+			return nil, ErrTokenPurpose
+		}
+		if claims.Act != nil && (purpose != "" || claims.Act.Subject == "") {
+			return nil, ErrTokenPurpose
+		}
 		return claims, nil
 	}
 	return nil, errors.New("invalid token")
 }
+
+// keyFunc picks the verification key by "kid" and rejects algorithm mismatches.
+func (m *JWTManager) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	k, ok := m.keys[kid]
+	if !ok {
+		return nil, errors.New("unknown signing key")
+	}
+	if t.Method.Alg() != k.Method.Alg() {
+		return nil, errors.New("unexpected signing method")
+	}
+	return k.verifyKey(), nil
+}
@@ -0,0 +1,180 @@
+/* Place: backend/go/auth/password_hasher.go */
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// Password hash algorithms. Stored hashes are self-describing ($2a$/$2b$ for bcrypt,
+// PHC "$argon2id$v=19$m=..,t=..,p=..$salt$hash" for Argon2id), so the version travels with the row.
+const (
+	PasswordAlgoBcrypt   = "bcrypt"
+	PasswordAlgoArgon2id = "argon2id"
+)
+
+// BcryptMaxPasswordBytes is the longest password bcrypt accepts.
+const BcryptMaxPasswordBytes = 72
+
+var ErrUnknownPasswordHash = errors.New("unknown password hash format")
+
+// PasswordHasher hashes new passwords and verifies stored ones.
+type PasswordHasher interface {
+	Hash(password string) (string, error)
+	// Verify reports whether password matches hash and whether hash uses outdated
+	// algorithm/parameters and should be replaced with Hash(password).
+	Verify(hash, password string) (ok bool, needsRehash bool, err error)
+	// MaxPasswordBytes is the longest password Hash accepts, 0 for no limit.
+	MaxPasswordBytes() int
+}
+
+// BcryptParams configures bcrypt hashing.
+type BcryptParams struct {
+	Cost int
+}
+
+// Argon2idParams configures Argon2id hashing (RFC 9106).
+type Argon2idParams struct {
+	Time      uint32
+	MemoryKiB uint32
+	Threads   uint8
+	SaltLen   uint32
+	KeyLen    uint32
+}
+
+// DefaultArgon2idParams follows the RFC 9106 second recommended option.
+var DefaultArgon2idParams = Argon2idParams{Time: 3, MemoryKiB: 64 * 1024, Threads: 4, SaltLen: 16, KeyLen: 32}
+
+// validate rejects parameters argon2.IDKey would panic on or silently weaken: it needs at
+// least one pass and one lane, and memory is raised to 8 KiB per lane behind our back.
+func (p Argon2idParams) validate() error {
+	switch {
+	case p.Time < 1:
+		return errors.New("argon2id time must be at least 1")
+	case p.Threads < 1:
+		return errors.New("argon2id threads must be between 1 and 255")
+	case p.MemoryKiB < 8*uint32(p.Threads):
+		return fmt.Errorf("argon2id memory must be at least %d KiB for %d threads", 8*uint32(p.Threads), p.Threads)
+	case p.SaltLen < 8:
+		return errors.New("argon2id salt must be at least 8 bytes")
+	case p.KeyLen < 16:
+		return errors.New("argon2id key must be at least 16 bytes")
+	}
+	return nil
+}
+
+// VersionedHasher hashes with the preferred algorithm and verifies any supported format,
+// flagging hashes made with another algorithm or weaker parameters for rehash.
+type VersionedHasher struct {
+	algo   string
+	bcrypt BcryptParams
+	argon  Argon2idParams
+}
+
+// NewPasswordHasher returns a hasher that writes algo ("bcrypt" or "argon2id") hashes.
+func NewPasswordHasher(algo string, bc BcryptParams, argon Argon2idParams) (*VersionedHasher, error) {
+	switch algo {
+	case PasswordAlgoBcrypt, PasswordAlgoArgon2id:
+	default:
+		return nil, fmt.Errorf("unsupported password algorithm %q", algo)
+	}
+	if algo == PasswordAlgoArgon2id {
+		if err := argon.validate(); err != nil {
+			return nil, err
+		}
+	}
+	return &VersionedHasher{algo: algo, bcrypt: bc, argon: argon}, nil
+}
+
+func (h *VersionedHasher) Hash(password string) (string, error) {
+	if h.algo == PasswordAlgoArgon2id {
+		return hashArgon2id(password, h.argon)
+	}
+	return HashPassword(password, h.bcrypt.Cost)
+}
+
+func (h *VersionedHasher) MaxPasswordBytes() int {
+	if h.algo == PasswordAlgoBcrypt {
+		return BcryptMaxPasswordBytes
+	}
+	return 0
+}
+
+func (h *VersionedHasher) Verify(hash, password string) (bool, bool, error) {
+	switch {
+	case strings.HasPrefix(hash, "$argon2id$"):
+		p, salt, key, err := parseArgon2id(hash)
+		if err != nil {
+			return false, false, err
+		}
+		got := argon2.IDKey([]byte(password), salt, p.Time, p.MemoryKiB, p.Threads, uint32(len(key)))
+		if subtle.ConstantTimeCompare(got, key) != 1 {
+			return false, false, nil
+		}
+		outdated := h.algo != PasswordAlgoArgon2id ||
+			p.Time < h.argon.Time || p.MemoryKiB < h.argon.MemoryKiB || p.Threads < h.argon.Threads ||
+			uint32(len(key)) < h.argon.KeyLen
+		return true, outdated, nil
+	case strings.HasPrefix(hash, "$2"):
+		if err := ComparePassword(hash, password); err != nil {
+			if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+				return false, false, nil
+			}
+			return false, false, err
+		}
+		cost, err := bcrypt.Cost([]byte(hash))
+		if err != nil {
+			return true, true, nil
+		}
+		outdated := h.algo != PasswordAlgoBcrypt || cost < h.bcrypt.Cost
+		return true, outdated, nil
+	default:
+		return false, false, ErrUnknownPasswordHash
+	}
+}
+
+func hashArgon2id(password string, p Argon2idParams) (string, error) {
+	salt := make([]byte, p.SaltLen)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey([]byte(password), salt, p.Time, p.MemoryKiB, p.Threads, p.KeyLen)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s",
+		argon2.Version, p.MemoryKiB, p.Time, p.Threads,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func parseArgon2id(hash string) (Argon2idParams, []byte, []byte, error) {
+	var p Argon2idParams
+	parts := strings.Split(hash, "$")
+	// "", "argon2id", "v=19", "m=..,t=..,p=..", salt, key
+	if len(parts) != 6 {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.MemoryKiB, &p.Time, &p.Threads); err != nil {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil {
+		return p, nil, nil, ErrUnknownPasswordHash
+	}
+	p.SaltLen = uint32(len(salt))
+	p.KeyLen = uint32(len(key))
+	return p, salt, key, nil
+}
@@ -0,0 +1,31 @@
+/* Place: backend/go/auth/roles.go */
+package auth
+
+// Known roles. Every account implicitly has RoleUser.
+const (
+	RoleAdmin               = "admin"
+	RoleModerator           = "moderator"
+	RoleTournamentOrganizer = "tournament_organizer"
+	RoleUser                = "user"
+)
+
+// IsValidRole reports whether role is one of the known roles.
+func IsValidRole(role string) bool {
+	switch role {
+	case RoleAdmin, RoleModerator, RoleTournamentOrganizer, RoleUser:
+		return true
+	}
+	return false
+}
+
+// HasRole reports whether the claims carry any of the given roles.
+func (c *Claims) HasRole(roles ...string) bool {
+	for _, have := range c.Roles {
+		for _, want := range roles {
+			if have == want {
+				return true
+			}
+		}
+	}
+	return false
+}
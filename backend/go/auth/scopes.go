@@ -0,0 +1,32 @@
+/* Place: backend/go/auth/scopes.go */
+package auth
+
+// API key scopes. Interactive (JWT) sessions are not scoped; API keys only get what they list.
+const (
+	ScopeProfileRead          = "profile:read"
+	ScopeTournamentRead       = "tournament:read"
+	ScopeTournamentScoreWrite = "tournament:score:write"
+)
+
+// IsValidScope reports whether scope is one of the known scopes.
+func IsValidScope(scope string) bool {
+	switch scope {
+	case ScopeProfileRead, ScopeTournamentRead, ScopeTournamentScoreWrite:
+		return true
+	}
+	return false
+}
+
+// HasScope reports whether the claims allow scope. Claims from an access token (no API key)
+// allow every scope; the route's own role checks still apply.
+func (c *Claims) HasScope(scope string) bool {
+	if c.APIKeyID == "" {
+		return true
+	}
+	for _, s := range c.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,61 @@
+/* Place: backend/go/auth/secretbox.go */
+package auth
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+)
+
+// SecretBox encrypts small secrets (e.g. TOTP seeds) that must be readable again, unlike
+// passwords and tokens which are only ever hashed. AES-256-GCM with a random nonce.
+type SecretBox struct {
+	aead cipher.AEAD
+}
+
+// NewSecretBox derives the AES key from passphrase with SHA-256.
+func NewSecretBox(passphrase string) (*SecretBox, error) {
+	if passphrase == "" {
+		return nil, errors.New("secret box passphrase is empty")
+	}
+	key := sha256.Sum256([]byte(passphrase))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &SecretBox{aead: aead}, nil
+}
+
+// Seal encrypts plaintext and returns nonce||ciphertext, base64 url encoded.
+func (b *SecretBox) Seal(plaintext string) (string, error) {
+	nonce := make([]byte, b.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	out := b.aead.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.RawURLEncoding.EncodeToString(out), nil
+}
+
+// Open reverses Seal.
+func (b *SecretBox) Open(sealed string) (string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	n := b.aead.NonceSize()
+	if len(raw) < n {
+		return "", errors.New("sealed secret too short")
+	}
+	pt, err := b.aead.Open(nil, raw[:n], raw[n:], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(pt), nil
+}
@@ -2,16 +2,21 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"math"
 	"net/http"
+	"strings"
 	"time"
 
 	"gatherup/api"
 	"gatherup/auth"
 	"gatherup/config"
 	"gatherup/db"
+	"gatherup/notify"
 	"gatherup/repository"
 	"gatherup/service"
+	"gatherup/storage"
 
 	_ "github.com/denisenkom/go-mssqldb"
 )
@@ -26,16 +31,112 @@ func main() {
 	defer dbConn.Close()
 
 	userRepo := repository.NewUserRepo(dbConn, nil, nil)
-	jwtMgr := auth.NewJWTManager(cfg.JWTSecret, cfg.AccessTokenTTL)
+	auditRepo := repository.NewAuditRepo(dbConn, nil, nil)
+	otpRepo := repository.NewOTPRepo(dbConn, nil, nil)
+	apiKeyRepo := repository.NewAPIKeyRepo(dbConn, nil, nil)
+	exportRepo := repository.NewExportRepo(dbConn, nil, nil)
+	contactRepo := repository.NewContactRepo(dbConn, nil, nil)
+	blockRepo := repository.NewBlockRepo(dbConn, nil, nil)
+	jwtMgr, err := newJWTManager(cfg)
+	if err != nil {
+		log.Fatalf("jwt keys: %v", err)
+	}
+
+	smsSender, err := notify.NewSMSSender(cfg.SMSSender, cfg.SMSFilePath)
+	if err != nil {
+		log.Fatalf("sms sender: %v", err)
+	}
+	emailSender, err := notify.NewEmailSender(cfg.EmailSender, cfg.EmailFilePath)
+	if err != nil {
+		log.Fatalf("email sender: %v", err)
+	}
+	if err := requireDistinctSecret("OTP_SECRET", cfg.OTPSecret, cfg.JWTSecret); err != nil {
+		log.Fatalf("otp secret: %v", err)
+	}
+	otpSvc := service.NewOTPService(otpRepo, smsSender, emailSender, &service.OTPConfig{
+		Digits:         cfg.OTPDigits,
+		TTL:            cfg.OTPTTL,
+		MaxAttempts:    cfg.OTPMaxAttempts,
+		ResendInterval: cfg.OTPResendInterval,
+		MaxPerHour:     cfg.OTPMaxPerHour,
+		Secret:         cfg.OTPSecret,
+	})
 
+	argonParams, err := newArgon2idParams(cfg)
+	if err != nil {
+		log.Fatalf("password hasher: %v", err)
+	}
+	hasher, err := auth.NewPasswordHasher(cfg.PasswordAlgo, auth.BcryptParams{Cost: cfg.BcryptCost}, argonParams)
+	if err != nil {
+		log.Fatalf("password hasher: %v", err)
+	}
+
+	if err := requireDistinctSecret("TOTP_SECRET_KEY", cfg.TOTPSecretKey, cfg.JWTSecret); err != nil {
+		log.Fatalf("totp secret key: %v", err)
+	}
+	secretBox, err := auth.NewSecretBox(cfg.TOTPSecretKey)
+	if err != nil {
+		log.Fatalf("totp secret key: %v", err)
+	}
 	authCfg := &service.AuthConfig{
-		BcryptCost:        cfg.BcryptCost,
-		RefreshTokenBytes: cfg.RefreshTokenBytes,
-		RefreshTTL:        cfg.RefreshTokenTTL,
+		BcryptCost:                cfg.BcryptCost,
+		PasswordHasher:            hasher,
+		RefreshTokenBytes:         cfg.RefreshTokenBytes,
+		RefreshTTL:                cfg.RefreshTokenTTL,
+		RequireMobileVerification: cfg.RequireMobileVerification,
+		IdentifierGuard: service.LoginGuardConfig{
+			FreeAttempts:     cfg.LoginFreeAttempts,
+			BaseDelay:        cfg.LoginBaseDelay,
+			MaxDelay:         cfg.LoginMaxDelay,
+			LockoutThreshold: cfg.LoginLockoutThreshold,
+			LockoutDuration:  cfg.LoginLockoutDuration,
+			Window:           cfg.LoginLockoutDuration,
+		},
+		// an IP may front many users (NAT, campus wifi): only lock out, no per-failure delay
+		IPGuard: service.LoginGuardConfig{
+			FreeAttempts:     cfg.LoginIPLockoutThreshold,
+			LockoutThreshold: cfg.LoginIPLockoutThreshold,
+			LockoutDuration:  cfg.LoginLockoutDuration,
+			Window:           cfg.LoginLockoutDuration,
+		},
+		TOTPIssuer:          cfg.TOTPIssuer,
+		SecretBox:           secretBox,
+		MFAChallengeTTL:     cfg.MFAChallengeTTL,
+		RecoveryCodeCount:   cfg.RecoveryCodeCount,
+		PasswordlessLogin:   cfg.PasswordlessLogin,
+		DeletionGracePeriod: cfg.AccountDeletionGrace,
+		ImpersonationTTL:    cfg.ImpersonationTTL,
 	}
-	authSvc := service.NewAuthService(userRepo, jwtMgr, authCfg)
+	authSvc := service.NewAuthService(userRepo, auditRepo, otpSvc, jwtMgr, authCfg)
+
+	apiKeySvc := service.NewAPIKeyService(apiKeyRepo, userRepo, &service.APIKeyConfig{
+		DefaultTTL:    cfg.APIKeyDefaultTTL,
+		MaxTTL:        cfg.APIKeyMaxTTL,
+		MaxPerUser:    cfg.APIKeyMaxPerUser,
+		TouchInterval: time.Minute,
+	})
 
-	handler := api.WireRouter(userRepo, jwtMgr, authSvc)
+	exportStore, err := storage.NewLocalStorage(cfg.ExportStorageDir)
+	if err != nil {
+		log.Fatalf("export storage: %v", err)
+	}
+	exportSvc := service.NewExportService(exportRepo, exportStore, &service.ExportConfig{TTL: cfg.ExportTTL})
+
+	blockSvc := service.NewBlockService(blockRepo, userRepo)
+	contactSvc := service.NewContactService(contactRepo, userRepo, blockSvc)
+
+	discoverySvc := service.NewDiscoveryService(userRepo, &service.DiscoveryConfig{
+		Salt:              cfg.ContactDiscoverySalt,
+		MaxBatch:          cfg.ContactDiscoveryMaxBatch,
+		MaxBatchesPerHour: cfg.ContactDiscoveryMaxPerHour,
+	})
+	profileSvc := service.NewProfileService(userRepo, discoverySvc, &service.ProfileConfig{
+		UsernameChangeInterval: cfg.UsernameChangeInterval,
+	})
+
+	prefsSvc := service.NewPreferencesService(userRepo, &service.PreferencesConfig{CacheTTL: cfg.PreferencesCacheTTL})
+
+	handler := api.WireRouter(jwtMgr, authSvc, apiKeySvc, exportSvc, profileSvc, contactSvc, blockSvc, discoverySvc, prefsSvc)
 
 	srv := &http.Server{
 		Addr:         cfg.ServerAddr,
@@ -49,3 +150,61 @@ func main() {
 		log.Fatal(err)
 	}
 }
+
+// requireDistinctSecret rejects an unset secret, or one reused from JWT_SECRET: each key
+// protects different data and must not fall back to a shared or default value.
+func requireDistinctSecret(name, value, jwtSecret string) error {
+	if value == "" {
+		return fmt.Errorf("%s must be set", name)
+	}
+	if value == jwtSecret {
+		return fmt.Errorf("%s must differ from JWT_SECRET", name)
+	}
+	return nil
+}
+
+// newArgon2idParams range-checks the ARGON2_* settings before narrowing them, so e.g.
+// ARGON2_THREADS=256 fails instead of wrapping to 0.
+func newArgon2idParams(cfg *config.AppConfig) (auth.Argon2idParams, error) {
+	if cfg.Argon2Time < 1 || cfg.Argon2Time > math.MaxUint32 {
+		return auth.Argon2idParams{}, fmt.Errorf("ARGON2_TIME must be between 1 and %d", uint32(math.MaxUint32))
+	}
+	if cfg.Argon2MemoryKiB < 1 || cfg.Argon2MemoryKiB > math.MaxUint32 {
+		return auth.Argon2idParams{}, fmt.Errorf("ARGON2_MEMORY_KIB must be between 1 and %d", uint32(math.MaxUint32))
+	}
+	if cfg.Argon2Threads < 1 || cfg.Argon2Threads > math.MaxUint8 {
+		return auth.Argon2idParams{}, fmt.Errorf("ARGON2_THREADS must be between 1 and %d", math.MaxUint8)
+	}
+	return auth.Argon2idParams{
+		Time:      uint32(cfg.Argon2Time),
+		MemoryKiB: uint32(cfg.Argon2MemoryKiB),
+		Threads:   uint8(cfg.Argon2Threads),
+		SaltLen:   auth.DefaultArgon2idParams.SaltLen,
+		KeyLen:    auth.DefaultArgon2idParams.KeyLen,
+	}, nil
+}
+
+// newJWTManager builds an HS256 manager from JWT_SECRET, or an RS256/EdDSA manager from
+// JWT_KEYS ("kid=path,...") signing with JWT_ACTIVE_KID; other listed keys are verify-only.
+func newJWTManager(cfg *config.AppConfig) (*auth.JWTManager, error) {
+	if cfg.JWTKeys == "" {
+		return auth.NewJWTManager(cfg.JWTSecret, cfg.AccessTokenTTL), nil
+	}
+	var keys []*auth.SigningKey
+	for _, entry := range strings.Split(cfg.JWTKeys, ",") {
+		kid, path, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || kid == "" || path == "" {
+			return nil, fmt.Errorf("invalid JWT_KEYS entry %q", entry)
+		}
+		k, err := auth.LoadSigningKeyFile(kid, path)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	if cfg.JWTVerifyHS256 {
+		// verify-only: tokens signed before the switch carry no kid
+		keys = append(keys, auth.NewHS256Key("", cfg.JWTSecret))
+	}
+	return auth.NewJWTManagerWithKeys(keys, cfg.JWTActiveKID, cfg.AccessTokenTTL)
+}
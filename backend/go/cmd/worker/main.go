@@ -0,0 +1,54 @@
+/* Place: backend/go/cmd/worker/main.go */
+package main
+
+import (
+	"context"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"gatherup/config"
+	"gatherup/db"
+	"gatherup/repository"
+	"gatherup/service"
+	"gatherup/storage"
+	"gatherup/worker"
+
+	_ "github.com/denisenkom/go-mssqldb"
+)
+
+// The worker runs background jobs next to the API server (any number of instances).
+func main() {
+	cfg := config.Load()
+
+	dbConn, err := db.Connect(cfg.DSN)
+	if err != nil {
+		log.Fatalf("db connect failed: %v", err)
+	}
+	defer dbConn.Close()
+
+	userRepo := repository.NewUserRepo(dbConn, nil, nil)
+	auditRepo := repository.NewAuditRepo(dbConn, nil, nil)
+	jobRepo := repository.NewJobRepo(dbConn, nil, nil)
+	exportRepo := repository.NewExportRepo(dbConn, nil, nil)
+
+	exportStore, err := storage.NewLocalStorage(cfg.ExportStorageDir)
+	if err != nil {
+		log.Fatalf("export storage: %v", err)
+	}
+	exportSvc := service.NewExportService(exportRepo, exportStore, &service.ExportConfig{TTL: cfg.ExportTTL})
+	discoverySvc := service.NewDiscoveryService(userRepo, &service.DiscoveryConfig{Salt: cfg.ContactDiscoverySalt})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	logger := log.New(os.Stdout, "WORKER: ", log.LstdFlags|log.Lmsgprefix)
+	logger.Printf("starting worker, interval %s", cfg.WorkerInterval)
+	worker.Run(ctx, cfg.WorkerInterval, logger,
+		worker.NewAccountPurger(userRepo, auditRepo, cfg.WorkerBatchSize),
+		worker.NewExportProcessor(jobRepo, exportSvc, cfg.WorkerBatchSize),
+		worker.NewMobileLookupIndexer(discoverySvc, cfg.WorkerBatchSize),
+	)
+	logger.Printf("worker stopped")
+}
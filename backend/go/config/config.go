@@ -9,24 +9,156 @@ import (
 
 // AppConfig collects runtime config values.
 type AppConfig struct {
-	DSN               string
-	JWTSecret         string
-	AccessTokenTTL    time.Duration
-	RefreshTokenTTL   time.Duration
-	BcryptCost        int
+	DSN       string
+	JWTSecret string
+	// JWTKeys lists asymmetric keys as "kid=path.pem,kid2=path2.pem"; empty means HS256 with JWTSecret.
+	JWTKeys      string
+	JWTActiveKID string
+	// JWTVerifyHS256 keeps accepting HS256 tokens (JWTSecret) while migrating to JWTKeys.
+	JWTVerifyHS256  bool
+	AccessTokenTTL  time.Duration
+	RefreshTokenTTL time.Duration
+	BcryptCost      int
+	// PasswordAlgo is the algorithm for new hashes: "bcrypt" or "argon2id".
+	// Existing hashes are upgraded on the next successful login.
+	PasswordAlgo      string
+	Argon2Time        int
+	Argon2MemoryKiB   int
+	Argon2Threads     int
 	ServerAddr        string
 	RefreshTokenBytes int
+
+	// OTP / SMS
+	OTPDigits         int
+	OTPTTL            time.Duration
+	OTPMaxAttempts    int
+	OTPResendInterval time.Duration
+	OTPMaxPerHour     int
+	OTPSecret         string // HMAC key of stored codes; required, must differ from JWTSecret
+	SMSSender         string // "console" or "file"
+	SMSFilePath       string
+	EmailSender       string // "console" or "file"
+	EmailFilePath     string
+	// RequireMobileVerification blocks login until the mobile number is verified by OTP.
+	RequireMobileVerification bool
+
+	// Login brute-force protection (per identifier; the per-IP threshold is separate)
+	LoginFreeAttempts       int
+	LoginBaseDelay          time.Duration
+	LoginMaxDelay           time.Duration
+	LoginLockoutThreshold   int
+	LoginLockoutDuration    time.Duration
+	LoginIPLockoutThreshold int
+
+	// TOTP two-factor authentication
+	TOTPIssuer string
+	// TOTPSecretKey encrypts stored TOTP secrets; changing it disables every enrolled authenticator.
+	// Required, and must differ from JWTSecret.
+	TOTPSecretKey     string
+	MFAChallengeTTL   time.Duration
+	RecoveryCodeCount int
+
+	// PasswordlessLogin enables POST /auth/login/otp (one-time code to a verified mobile or email).
+	PasswordlessLogin bool
+
+	// Personal / bot API keys
+	APIKeyDefaultTTL time.Duration
+	APIKeyMaxTTL     time.Duration
+	APIKeyMaxPerUser int
+
+	// UsernameChangeInterval is the minimum time between username changes.
+	UsernameChangeInterval time.Duration
+
+	// Admin impersonation tokens (support staff acting as a user)
+	ImpersonationTTL time.Duration
+
+	// Account deletion and background worker (cmd/worker)
+	AccountDeletionGrace time.Duration
+	WorkerInterval       time.Duration
+	WorkerBatchSize      int
+
+	// Personal data exports ("download my data")
+	ExportStorageDir string
+	ExportTTL        time.Duration
+
+	// Phone-book contact discovery; an empty salt disables it. Changing the salt requires
+	// clearing users.mobile_lookup_hash (see migrations/0012_mobile_lookup.sql).
+	ContactDiscoverySalt       string
+	ContactDiscoveryMaxBatch   int
+	ContactDiscoveryMaxPerHour int
+
+	// PreferencesCacheTTL is how long user preferences are cached per instance.
+	PreferencesCacheTTL time.Duration
 }
 
 func Load() *AppConfig {
 	c := &AppConfig{
 		DSN:               MsSQLDSN(),
 		JWTSecret:         JwtSecret(),
+		JWTKeys:           GetEnv("JWT_KEYS", ""),
+		JWTActiveKID:      GetEnv("JWT_ACTIVE_KID", ""),
+		JWTVerifyHS256:    getenvBool("JWT_VERIFY_HS256", false),
 		AccessTokenTTL:    getenvDuration("ACCESS_TOKEN_TTL", 30*24*time.Hour),
 		RefreshTokenTTL:   getenvDuration("REFRESH_TOKEN_TTL", 30*24*time.Hour),
 		BcryptCost:        getenvInt("BCRYPT_COST", 12),
+		PasswordAlgo:      GetEnv("PASSWORD_ALGO", "bcrypt"),
+		Argon2Time:        getenvInt("ARGON2_TIME", 3),
+		Argon2MemoryKiB:   getenvInt("ARGON2_MEMORY_KIB", 64*1024),
+		Argon2Threads:     getenvInt("ARGON2_THREADS", 4),
 		ServerAddr:        ":" + GetEnv("PORT", "8080"),
 		RefreshTokenBytes: getenvInt("REFRESH_BYTES", 32),
+
+		OTPDigits:                 getenvInt("OTP_DIGITS", 6),
+		OTPTTL:                    getenvDuration("OTP_TTL", 5*time.Minute),
+		OTPMaxAttempts:            getenvInt("OTP_MAX_ATTEMPTS", 5),
+		OTPResendInterval:         getenvDuration("OTP_RESEND_INTERVAL", 30*time.Second),
+		OTPMaxPerHour:             getenvInt("OTP_MAX_PER_HOUR", 5),
+		OTPSecret:                 GetEnv("OTP_SECRET", ""),
+		SMSSender:                 GetEnv("SMS_SENDER", "console"),
+		SMSFilePath:               GetEnv("SMS_FILE_PATH", "logs/sms.log"),
+		EmailSender:               GetEnv("EMAIL_SENDER", "console"),
+		EmailFilePath:             GetEnv("EMAIL_FILE_PATH", "logs/email.log"),
+		RequireMobileVerification: getenvBool("REQUIRE_MOBILE_VERIFICATION", false),
+
+		LoginFreeAttempts:       getenvInt("LOGIN_FREE_ATTEMPTS", 3),
+		LoginBaseDelay:          getenvDuration("LOGIN_BASE_DELAY", time.Second),
+		LoginMaxDelay:           getenvDuration("LOGIN_MAX_DELAY", 30*time.Second),
+		LoginLockoutThreshold:   getenvInt("LOGIN_LOCKOUT_THRESHOLD", 10),
+		LoginLockoutDuration:    getenvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginIPLockoutThreshold: getenvInt("LOGIN_IP_LOCKOUT_THRESHOLD", 50),
+
+		TOTPIssuer:        GetEnv("TOTP_ISSUER", "GatherUp"),
+		TOTPSecretKey:     GetEnv("TOTP_SECRET_KEY", ""),
+		MFAChallengeTTL:   getenvDuration("MFA_CHALLENGE_TTL", 5*time.Minute),
+		RecoveryCodeCount: getenvInt("RECOVERY_CODE_COUNT", 10),
+
+		PasswordlessLogin: getenvBool("PASSWORDLESS_LOGIN", false),
+
+		APIKeyDefaultTTL: getenvDuration("API_KEY_DEFAULT_TTL", 90*24*time.Hour),
+		APIKeyMaxTTL:     getenvDuration("API_KEY_MAX_TTL", 365*24*time.Hour),
+		APIKeyMaxPerUser: getenvInt("API_KEY_MAX_PER_USER", 20),
+
+		UsernameChangeInterval: getenvDuration("USERNAME_CHANGE_INTERVAL", 30*24*time.Hour),
+
+		ImpersonationTTL: getenvDuration("IMPERSONATION_TTL", 15*time.Minute),
+
+		AccountDeletionGrace: getenvDuration("ACCOUNT_DELETION_GRACE", 30*24*time.Hour),
+		WorkerInterval:       getenvDuration("WORKER_INTERVAL", time.Minute),
+		WorkerBatchSize:      getenvInt("WORKER_BATCH_SIZE", 50),
+
+		ExportStorageDir: GetEnv("EXPORT_STORAGE_DIR", "data/exports"),
+		ExportTTL:        getenvDuration("EXPORT_TTL", 7*24*time.Hour),
+
+		ContactDiscoverySalt:       GetEnv("CONTACT_DISCOVERY_SALT", ""),
+		ContactDiscoveryMaxBatch:   getenvInt("CONTACT_DISCOVERY_MAX_BATCH", 500),
+		ContactDiscoveryMaxPerHour: getenvInt("CONTACT_DISCOVERY_MAX_PER_HOUR", 10),
+
+		PreferencesCacheTTL: getenvDuration("PREFERENCES_CACHE_TTL", 5*time.Minute),
+	}
+	if c.ContactDiscoveryMaxBatch < 1 || c.ContactDiscoveryMaxBatch > 2000 {
+		// one SQL parameter per hash; SQL Server allows 2100
+		log.Println("Contact discovery batch out of range; using 500")
+		c.ContactDiscoveryMaxBatch = 500
 	}
 	if c.BcryptCost < 4 {
 		log.Println("Bcrypt cost too low; bumping to 12")
@@ -44,6 +176,15 @@ func getenvInt(key string, fallback int) int {
 	return fallback
 }
 
+func getenvBool(key string, fallback bool) bool {
+	if v := GetEnv(key, ""); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return fallback
+}
+
 func getenvDuration(key string, fallback time.Duration) time.Duration {
 	if v := GetEnv(key, ""); v != "" {
 		if d, err := time.ParseDuration(v); err == nil {
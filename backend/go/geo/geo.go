@@ -0,0 +1,92 @@
+/* Place: backend/go/geo/geo.go */
+// Package geo has the distance math used for location features. It is plain Go so it can be
+// tested and reasoned about without SQL Server's GEOGRAPHY functions; SQL only does a coarse
+// bounding-box prefilter.
+package geo
+
+import (
+	"errors"
+	"math"
+)
+
+// EarthRadiusKm is the mean Earth radius used by Haversine.
+const EarthRadiusKm = 6371.0088
+
+var ErrInvalidCoordinates = errors.New("latitude must be within [-90, 90] and longitude within [-180, 180]")
+
+// Point is a WGS84 coordinate in degrees.
+type Point struct {
+	Lat float64
+	Lon float64
+}
+
+// Validate rejects coordinates outside the valid ranges (and NaN).
+func (p Point) Validate() error {
+	if !(p.Lat >= -90 && p.Lat <= 90) || !(p.Lon >= -180 && p.Lon <= 180) {
+		return ErrInvalidCoordinates
+	}
+	return nil
+}
+
+// HaversineKm returns the great-circle distance between a and b in kilometres.
+func HaversineKm(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLon := radians(b.Lon - a.Lon)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * EarthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Box is a latitude/longitude rectangle. When it crosses the antimeridian MinLon > MaxLon.
+type Box struct {
+	MinLat, MaxLat float64
+	MinLon, MaxLon float64
+}
+
+// Contains reports whether p lies inside the box.
+func (b Box) Contains(p Point) bool {
+	if p.Lat < b.MinLat || p.Lat > b.MaxLat {
+		return false
+	}
+	if b.MinLon <= b.MaxLon {
+		return p.Lon >= b.MinLon && p.Lon <= b.MaxLon
+	}
+	return p.Lon >= b.MinLon || p.Lon <= b.MaxLon
+}
+
+// BoundingBox returns a box containing every point within radiusKm of center. Near the poles
+// it widens to all longitudes.
+func BoundingBox(center Point, radiusKm float64) Box {
+	dLat := degrees(radiusKm / EarthRadiusKm)
+	b := Box{MinLat: center.Lat - dLat, MaxLat: center.Lat + dLat, MinLon: -180, MaxLon: 180}
+	if b.MinLat <= -90 || b.MaxLat >= 90 {
+		b.MinLat, b.MaxLat = math.Max(b.MinLat, -90), math.Min(b.MaxLat, 90)
+		return b
+	}
+	dLon := degrees(math.Asin(math.Sin(radiusKm/EarthRadiusKm) / math.Cos(radians(center.Lat))))
+	if math.IsNaN(dLon) || dLon >= 180 {
+		return b
+	}
+	b.MinLon, b.MaxLon = wrapLon(center.Lon-dLon), wrapLon(center.Lon+dLon)
+	return b
+}
+
+// Round snaps p to decimals decimal places (4 ≈ 11 m, 2 ≈ 1.1 km, 1 ≈ 11 km), which hides the
+// exact position when storing a coarse location.
+func Round(p Point, decimals int) Point {
+	f := math.Pow(10, float64(decimals))
+	return Point{Lat: math.Round(p.Lat*f) / f, Lon: math.Round(p.Lon*f) / f}
+}
+
+func radians(d float64) float64 { return d * math.Pi / 180 }
+func degrees(r float64) float64 { return r * 180 / math.Pi }
+
+func wrapLon(lon float64) float64 {
+	switch {
+	case lon > 180:
+		return lon - 360
+	case lon < -180:
+		return lon + 360
+	}
+	return lon
+}
@@ -0,0 +1,178 @@
+/* Place: backend/go/geo/geo_test.go */
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+var (
+	london     = Point{Lat: 51.5074, Lon: -0.1278}
+	paris      = Point{Lat: 48.8566, Lon: 2.3522}
+	newYork    = Point{Lat: 40.7128, Lon: -74.0060}
+	losAngeles = Point{Lat: 34.0522, Lon: -118.2437}
+	sydney     = Point{Lat: -33.8688, Lon: 151.2093}
+	auckland   = Point{Lat: -36.8485, Lon: 174.7633}
+	tokyo      = Point{Lat: 35.6762, Lon: 139.6503}
+)
+
+func TestHaversineKm(t *testing.T) {
+	tests := []struct {
+		name string
+		a, b Point
+		want float64 // km
+	}{
+		{"same point", london, london, 0},
+		{"london-paris", london, paris, 343.6},
+		{"new york-los angeles", newYork, losAngeles, 3936},
+		{"sydney-auckland", sydney, auckland, 2156},
+		{"london-tokyo", london, tokyo, 9560},
+		{"across the antimeridian", Point{Lat: 0, Lon: 179.5}, Point{Lat: 0, Lon: -179.5}, 111.2},
+		{"pole to pole", Point{Lat: 90, Lon: 0}, Point{Lat: -90, Lon: 0}, math.Pi * EarthRadiusKm},
+		{"antipodes", Point{Lat: 0, Lon: 0}, Point{Lat: 0, Lon: 180}, math.Pi * EarthRadiusKm},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := HaversineKm(tt.a, tt.b)
+			if math.Abs(got-tt.want) > math.Max(0.5, tt.want*0.005) {
+				t.Errorf("HaversineKm = %.1f km, want %.1f km", got, tt.want)
+			}
+			if back := HaversineKm(tt.b, tt.a); math.Abs(back-got) > 1e-9 {
+				t.Errorf("HaversineKm is not symmetric: %.6f vs %.6f", got, back)
+			}
+		})
+	}
+}
+
+func TestBoundingBox(t *testing.T) {
+	tests := []struct {
+		name     string
+		center   Point
+		radiusKm float64
+		want     Box
+	}{
+		{"equator", Point{Lat: 0, Lon: 0}, 111.195, Box{MinLat: -1, MaxLat: 1, MinLon: -1, MaxLon: 1}},
+		{"crosses the antimeridian", Point{Lat: 0, Lon: 179.5}, 111.195, Box{MinLat: -1, MaxLat: 1, MinLon: 178.5, MaxLon: -179.5}},
+		{"crosses the antimeridian westward", Point{Lat: 0, Lon: -179.5}, 111.195, Box{MinLat: -1, MaxLat: 1, MinLon: 179.5, MaxLon: -178.5}},
+		{"reaches the north pole", Point{Lat: 89.5, Lon: 10}, 111.195, Box{MinLat: 88.5, MaxLat: 90, MinLon: -180, MaxLon: 180}},
+		{"reaches the south pole", Point{Lat: -89.5, Lon: 10}, 111.195, Box{MinLat: -90, MaxLat: -88.5, MinLon: -180, MaxLon: 180}},
+		{"at the pole", Point{Lat: 90, Lon: 0}, 1, Box{MinLat: 89.991, MaxLat: 90, MinLon: -180, MaxLon: 180}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BoundingBox(tt.center, tt.radiusKm)
+			for _, f := range []struct {
+				field     string
+				got, want float64
+			}{
+				{"MinLat", got.MinLat, tt.want.MinLat},
+				{"MaxLat", got.MaxLat, tt.want.MaxLat},
+				{"MinLon", got.MinLon, tt.want.MinLon},
+				{"MaxLon", got.MaxLon, tt.want.MaxLon},
+			} {
+				if math.Abs(f.got-f.want) > 1e-3 {
+					t.Errorf("%s = %.4f, want %.4f (box %+v)", f.field, f.got, f.want, got)
+				}
+			}
+		})
+	}
+}
+
+// Every point within the radius must fall inside the box, including the one due east/west.
+func TestBoundingBoxContainsRadius(t *testing.T) {
+	centers := []Point{
+		london, sydney, {Lat: 0, Lon: 179.9}, {Lat: 0, Lon: -179.9}, {Lat: 60, Lon: 179}, {Lat: 89.9, Lon: 0}, {Lat: -89.9, Lon: 0},
+	}
+	const radiusKm = 50
+	for _, c := range centers {
+		box := BoundingBox(c, radiusKm)
+		for bearing := 0.0; bearing < 360; bearing += 15 {
+			p := destination(c, bearing, radiusKm*0.999)
+			if !box.Contains(p) {
+				t.Errorf("center %+v: point %+v at bearing %.0f is outside %+v", c, p, bearing, box)
+			}
+		}
+	}
+}
+
+func TestBoxContains(t *testing.T) {
+	plain := Box{MinLat: 10, MaxLat: 20, MinLon: 30, MaxLon: 40}
+	wrapped := Box{MinLat: -10, MaxLat: 10, MinLon: 170, MaxLon: -170}
+	polar := Box{MinLat: 80, MaxLat: 90, MinLon: -180, MaxLon: 180}
+	tests := []struct {
+		name string
+		box  Box
+		p    Point
+		want bool
+	}{
+		{"inside", plain, Point{Lat: 15, Lon: 35}, true},
+		{"on the edge", plain, Point{Lat: 10, Lon: 40}, true},
+		{"south of the box", plain, Point{Lat: 9.9, Lon: 35}, false},
+		{"east of the box", plain, Point{Lat: 15, Lon: 40.1}, false},
+		{"wrapped, east side", wrapped, Point{Lat: 0, Lon: 175}, true},
+		{"wrapped, west side", wrapped, Point{Lat: 0, Lon: -175}, true},
+		{"wrapped, on the antimeridian", wrapped, Point{Lat: 0, Lon: 180}, true},
+		{"wrapped, on the other side of the world", wrapped, Point{Lat: 0, Lon: 0}, false},
+		{"wrapped, outside latitude", wrapped, Point{Lat: 11, Lon: 175}, false},
+		{"polar, any longitude", polar, Point{Lat: 85, Lon: -120}, true},
+		{"polar, the pole", polar, Point{Lat: 90, Lon: 0}, true},
+		{"polar, too far south", polar, Point{Lat: 79, Lon: 0}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.box.Contains(tt.p); got != tt.want {
+				t.Errorf("%+v.Contains(%+v) = %v, want %v", tt.box, tt.p, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRound(t *testing.T) {
+	p := Point{Lat: 51.5073509, Lon: -0.1277583}
+	tests := []struct {
+		decimals int
+		want     Point
+	}{
+		{6, Point{Lat: 51.507351, Lon: -0.127758}},
+		{4, Point{Lat: 51.5074, Lon: -0.1278}},
+		{3, Point{Lat: 51.507, Lon: -0.128}},
+		{2, Point{Lat: 51.51, Lon: -0.13}},
+		{1, Point{Lat: 51.5, Lon: -0.1}},
+		{0, Point{Lat: 52, Lon: 0}},
+	}
+	for _, tt := range tests {
+		got := Round(p, tt.decimals)
+		if math.Abs(got.Lat-tt.want.Lat) > 1e-9 || math.Abs(got.Lon-tt.want.Lon) > 1e-9 {
+			t.Errorf("Round(%d) = %+v, want %+v", tt.decimals, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		p    Point
+		want error
+	}{
+		{Point{Lat: 0, Lon: 0}, nil},
+		{Point{Lat: 90, Lon: 180}, nil},
+		{Point{Lat: -90, Lon: -180}, nil},
+		{Point{Lat: 90.1, Lon: 0}, ErrInvalidCoordinates},
+		{Point{Lat: 0, Lon: -180.1}, ErrInvalidCoordinates},
+		{Point{Lat: math.NaN(), Lon: 0}, ErrInvalidCoordinates},
+		{Point{Lat: 0, Lon: math.Inf(1)}, ErrInvalidCoordinates},
+	}
+	for _, tt := range tests {
+		if got := tt.p.Validate(); got != tt.want {
+			t.Errorf("%+v.Validate() = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+// destination returns the point distKm from p along bearing (degrees clockwise from north).
+func destination(p Point, bearing, distKm float64) Point {
+	lat1, lon1, brg := radians(p.Lat), radians(p.Lon), radians(bearing)
+	d := distKm / EarthRadiusKm
+	lat2 := math.Asin(math.Sin(lat1)*math.Cos(d) + math.Cos(lat1)*math.Sin(d)*math.Cos(brg))
+	lon2 := lon1 + math.Atan2(math.Sin(brg)*math.Sin(d)*math.Cos(lat1), math.Cos(d)-math.Sin(lat1)*math.Sin(lat2))
+	return Point{Lat: degrees(lat2), Lon: wrapLon(degrees(lon2))}
+}
@@ -0,0 +1,17 @@
+/* Place: backend/go/models/audit.go */
+package models
+
+import "time"
+
+// AuditLog represents a row in dbo.audit_logs (security and admin events).
+type AuditLog struct {
+	ID          int64     `json:"id"`
+	EntityType  string    `json:"entity_type"`
+	EntityID    string    `json:"entity_id"`
+	Action      string    `json:"action"`
+	PerformedBy *string   `json:"performed_by,omitempty"`
+	UserAgent   *string   `json:"user_agent,omitempty"`
+	IPAddress   *string   `json:"ip_address,omitempty"`
+	Payload     *string   `json:"payload,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
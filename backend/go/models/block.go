@@ -0,0 +1,11 @@
+/* Place: backend/go/models/block.go */
+package models
+
+import "time"
+
+// BlockedUser is one entry of the caller's block list.
+type BlockedUser struct {
+	User      UserSummary `json:"user"`
+	Reason    *string     `json:"reason,omitempty"`
+	BlockedAt time.Time   `json:"blocked_at"`
+}
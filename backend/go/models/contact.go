@@ -0,0 +1,36 @@
+/* Place: backend/go/models/contact.go */
+package models
+
+import "time"
+
+// Contact statuses (dbo.contacts.status).
+const (
+	ContactPending  = "pending"
+	ContactAccepted = "accepted"
+	ContactDeclined = "declined"
+)
+
+// Directions of a contact request as seen by the listing user.
+const (
+	ContactIncoming = "incoming"
+	ContactOutgoing = "outgoing"
+)
+
+// Contact represents a row in dbo.contacts: UserID sent a request to ContactUserID.
+type Contact struct {
+	ID            int64      `json:"-"`
+	UserID        string     `json:"-"`
+	ContactUserID string     `json:"-"`
+	Status        string     `json:"status"`
+	CreatedAt     time.Time  `json:"created_at"`
+	AcceptedAt    *time.Time `json:"accepted_at,omitempty"`
+}
+
+// ContactEntry is one line of a contact list: the other user and the request state.
+type ContactEntry struct {
+	User       UserSummary `json:"user"`
+	Status     string      `json:"status"`
+	Direction  string      `json:"direction"`
+	CreatedAt  time.Time   `json:"created_at"`
+	AcceptedAt *time.Time  `json:"accepted_at,omitempty"`
+}
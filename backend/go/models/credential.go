@@ -0,0 +1,14 @@
+/* Place: backend/go/models/credential.go */
+package models
+
+import "time"
+
+// Credential represents a row in dbo.user_credentials without secret material.
+type Credential struct {
+	ID         int64      `json:"id"`
+	UserID     string     `json:"-"`
+	Type       string     `json:"type"`
+	Identifier *string    `json:"identifier,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	LastUsed   *time.Time `json:"last_used,omitempty"`
+}
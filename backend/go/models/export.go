@@ -0,0 +1,25 @@
+/* Place: backend/go/models/export.go */
+package models
+
+import "time"
+
+// Data export statuses (dbo.data_exports.status).
+const (
+	ExportPending   = "pending"
+	ExportRunning   = "running"
+	ExportCompleted = "completed"
+	ExportFailed    = "failed"
+)
+
+// DataExport represents a row in dbo.data_exports.
+type DataExport struct {
+	ID          string     `json:"id"`
+	UserID      string     `json:"-"`
+	Status      string     `json:"status"`
+	StorageKey  *string    `json:"-"`
+	SizeBytes   *int64     `json:"size_bytes,omitempty"`
+	Error       *string    `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
@@ -0,0 +1,11 @@
+/* Place: backend/go/models/job.go */
+package models
+
+// Job represents a claimed row of dbo.jobs.
+type Job struct {
+	ID          int64
+	Topic       string
+	Payload     string
+	Attempts    int
+	MaxAttempts int
+}
@@ -0,0 +1,29 @@
+/* Place: backend/go/models/notification.go */
+package models
+
+import "time"
+
+// Notification kinds. Message, like and comment notifications can be turned off in
+// UserPreferences; the others are always sent.
+const (
+	NotificationContactRequest  = "contact_request"
+	NotificationContactAccepted = "contact_accepted"
+	NotificationMessage         = "message"
+	NotificationLike            = "like"
+	NotificationComment         = "comment"
+)
+
+// Notification represents a row in dbo.notifications.
+type Notification struct {
+	ID            int64      `json:"id"`
+	UserID        string     `json:"-"`
+	ActorID       *string    `json:"actor_id,omitempty"`
+	Kind          string     `json:"kind"`
+	ReferenceType *string    `json:"reference_type,omitempty"`
+	ReferenceID   *string    `json:"reference_id,omitempty"`
+	Title         *string    `json:"title,omitempty"`
+	Body          *string    `json:"body,omitempty"`
+	IsRead        bool       `json:"is_read"`
+	CreatedAt     time.Time  `json:"created_at"`
+	ReadAt        *time.Time `json:"read_at,omitempty"`
+}
@@ -0,0 +1,19 @@
+/* Place: backend/go/models/otp.go */
+package models
+
+import "time"
+
+// OTPCode represents a row in dbo.otp_codes. The raw code is never stored.
+type OTPCode struct {
+	ID          string
+	UserID      *string
+	Purpose     string
+	Channel     string
+	Destination string
+	CodeHash    string
+	Attempts    int
+	MaxAttempts int
+	ExpiresAt   time.Time
+	ConsumedAt  *time.Time
+	CreatedAt   time.Time
+}
@@ -0,0 +1,39 @@
+/* Place: backend/go/models/preferences.go */
+package models
+
+// Defaults used while a user has no dbo.user_preferences row or a column is NULL.
+const (
+	DefaultTimezone = "UTC"
+	DefaultLang     = "en"
+	DefaultTheme    = ThemeLight
+)
+
+// UI themes (dbo.user_preferences.theme).
+const (
+	ThemeLight  = "light"
+	ThemeDark   = "dark"
+	ThemeSystem = "system"
+)
+
+// UserPreferences represents dbo.user_preferences with defaults applied.
+type UserPreferences struct {
+	NotifyOnMessage bool `json:"notify_on_message"`
+	NotifyOnLike    bool `json:"notify_on_like"`
+	NotifyOnComment bool `json:"notify_on_comment"`
+	// Timezone is an IANA zone name, Lang a BCP 47 language tag.
+	Timezone string `json:"timezone"`
+	Lang     string `json:"lang"`
+	Theme    string `json:"theme"`
+}
+
+// DefaultPreferences returns the preferences of a user who never changed any.
+func DefaultPreferences() UserPreferences {
+	return UserPreferences{
+		NotifyOnMessage: true,
+		NotifyOnLike:    true,
+		NotifyOnComment: true,
+		Timezone:        DefaultTimezone,
+		Lang:            DefaultLang,
+		Theme:           DefaultTheme,
+	}
+}
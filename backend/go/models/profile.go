@@ -0,0 +1,67 @@
+/* Place: backend/go/models/profile.go */
+package models
+
+import "time"
+
+// Relationship of a viewer to another user; it decides which profile fields are visible.
+// Blocked users (either direction) get no profile at all.
+const (
+	RelationshipSelf     = "self"
+	RelationshipContact  = "contact"
+	RelationshipStranger = "stranger"
+)
+
+// UserSkill represents a row in dbo.user_skills joined with its game type.
+type UserSkill struct {
+	GameTypeID      int     `json:"game_type_id"`
+	GameTypeCode    string  `json:"game_type"`
+	GameTypeName    string  `json:"game_type_name"`
+	SkillLevel      *string `json:"skill_level,omitempty"`
+	ExperienceYears *int    `json:"experience_years,omitempty"`
+	IsPublic        bool    `json:"is_public"`
+}
+
+// ProfileStats are aggregate counters shown on a profile.
+type ProfileStats struct {
+	// Posts counts the posts the viewer is allowed to see.
+	Posts                int `json:"posts"`
+	TournamentsPlayed    int `json:"tournaments_played"`
+	TournamentsOrganized int `json:"tournaments_organized"`
+}
+
+// PublicProfile is another user's profile as projected for a viewer.
+type PublicProfile struct {
+	ID           string       `json:"id"`
+	Username     *string      `json:"username,omitempty"`
+	DisplayName  *string      `json:"display_name,omitempty"`
+	AvatarURL    *string      `json:"avatar_url,omitempty"`
+	Bio          *string      `json:"bio,omitempty"`
+	IsBot        bool         `json:"is_bot,omitempty"`
+	MemberSince  time.Time    `json:"member_since"`
+	Relationship string       `json:"relationship"`
+	Skills       []UserSkill  `json:"skills"`
+	Stats        ProfileStats `json:"stats"`
+
+	// contacts only
+	Gender *string `json:"gender,omitempty"`
+	Age    *int    `json:"age,omitempty"`
+}
+
+// UserSummary is the short user card used in lists such as search results.
+type UserSummary struct {
+	ID          string  `json:"id"`
+	Username    *string `json:"username,omitempty"`
+	DisplayName *string `json:"display_name,omitempty"`
+	AvatarURL   *string `json:"avatar_url,omitempty"`
+	IsBot       bool    `json:"is_bot,omitempty"`
+	IsContact   bool    `json:"is_contact"`
+}
+
+// NearbyUser is a nearby-players result. DistanceKm is rounded up to whole kilometres so
+// exact positions cannot be triangulated.
+type NearbyUser struct {
+	UserSummary
+	DistanceKm float64 `json:"distance_km"`
+	// SkillLevel is set when the search was filtered by game type.
+	SkillLevel *string `json:"skill_level,omitempty"`
+}
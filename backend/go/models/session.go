@@ -0,0 +1,19 @@
+/* Place: backend/go/models/session.go */
+package models
+
+import "time"
+
+// UserSession represents a row in dbo.user_sessions. Its id equals the session_id
+// of the refresh token family created at login.
+type UserSession struct {
+	ID               string     `json:"id"`
+	UserID           string     `json:"-"`
+	DeviceInfo       *string    `json:"device_info,omitempty"`
+	IPAddress        *string    `json:"ip_address,omitempty"`
+	SessionLatitude  *float64   `json:"latitude,omitempty"`
+	SessionLongitude *float64   `json:"longitude,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	ExpiresAt        *time.Time `json:"expires_at,omitempty"`
+	LastActivityAt   *time.Time `json:"last_activity_at,omitempty"`
+	IsRevoked        bool       `json:"-"`
+}
@@ -14,6 +14,8 @@ type User struct {
 	Bio              *string `json:"bio,omitempty"`
 	Email            *string `json:"email,omitempty"`
 	Username         *string `json:"username,omitempty"`
+	// UsernameChangedAt is when Username was last changed (changes are rate-limited).
+	UsernameChangedAt *time.Time `json:"username_changed_at,omitempty"`
 
 	Latitude          *float64   `json:"latitude,omitempty"`
 	Longitude         *float64   `json:"longitude,omitempty"`
@@ -26,6 +28,9 @@ type User struct {
 	MobileVerifiedAt *time.Time `json:"mobile_verified_at,omitempty"`
 	IsEmailVerified  *bool      `json:"is_email_verified,omitempty"`
 	IsActive         bool       `json:"is_active,omitempty"`
+	// IsBot accounts are owned by BotOwnerID and authenticate only with API keys.
+	IsBot      bool    `json:"is_bot,omitempty"`
+	BotOwnerID *string `json:"bot_owner_id,omitempty"`
 
 	CreatedAt time.Time  `json:"created_at"`
 	UpdatedAt *time.Time `json:"updated_at,omitempty"`
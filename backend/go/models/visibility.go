@@ -0,0 +1,10 @@
+/* Place: backend/go/models/visibility.go */
+package models
+
+// Visibility ids (dbo.visibility_types) used by posts and tournaments.
+const (
+	VisibilityPrivate  = 0
+	VisibilityContacts = 1
+	VisibilityPublic   = 2
+	VisibilityGroup    = 3
+)
@@ -0,0 +1,75 @@
+/* Place: backend/go/notify/email.go */
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// EmailSender delivers a plain-text email. Production providers implement this;
+// console/file senders are for local dev and tests.
+type EmailSender interface {
+	SendEmail(ctx context.Context, to, subject, body string) error
+}
+
+// NewEmailSender builds a sender by kind: "console" (default) or "file".
+func NewEmailSender(kind, path string) (EmailSender, error) {
+	switch kind {
+	case "", "console":
+		return NewConsoleEmailSender(nil), nil
+	case "file":
+		return NewFileEmailSender(path)
+	default:
+		return nil, fmt.Errorf("unknown email sender %q", kind)
+	}
+}
+
+// ConsoleEmailSender prints messages to a logger (stdout by default).
+type ConsoleEmailSender struct {
+	logger *log.Logger
+}
+
+func NewConsoleEmailSender(logger *log.Logger) *ConsoleEmailSender {
+	if logger == nil {
+		logger = log.New(os.Stdout, "EMAIL: ", log.LstdFlags|log.Lmsgprefix)
+	}
+	return &ConsoleEmailSender{logger: logger}
+}
+
+func (s *ConsoleEmailSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	s.logger.Printf("to=%s subject=%q body=%q", to, subject, body)
+	return nil
+}
+
+// FileEmailSender appends one line per message to a file.
+type FileEmailSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileEmailSender(path string) (*FileEmailSender, error) {
+	if path == "" {
+		path = "logs/email.log"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return &FileEmailSender{path: path}, nil
+}
+
+func (s *FileEmailSender) SendEmail(ctx context.Context, to, subject, body string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\t%s\n", time.Now().UTC().Format(time.RFC3339), to, subject, body)
+	return err
+}
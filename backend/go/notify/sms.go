@@ -0,0 +1,75 @@
+/* Place: backend/go/notify/sms.go */
+package notify
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SMSSender delivers a text message to a phone number (E.164 or normalized digits).
+// Production gateways implement this; console/file senders are for local dev and tests.
+type SMSSender interface {
+	SendSMS(ctx context.Context, to, body string) error
+}
+
+// NewSMSSender builds a sender by kind: "console" (default) or "file".
+func NewSMSSender(kind, path string) (SMSSender, error) {
+	switch kind {
+	case "", "console":
+		return NewConsoleSMSSender(nil), nil
+	case "file":
+		return NewFileSMSSender(path)
+	default:
+		return nil, fmt.Errorf("unknown sms sender %q", kind)
+	}
+}
+
+// ConsoleSMSSender prints messages to a logger (stdout by default).
+type ConsoleSMSSender struct {
+	logger *log.Logger
+}
+
+func NewConsoleSMSSender(logger *log.Logger) *ConsoleSMSSender {
+	if logger == nil {
+		logger = log.New(os.Stdout, "SMS: ", log.LstdFlags|log.Lmsgprefix)
+	}
+	return &ConsoleSMSSender{logger: logger}
+}
+
+func (s *ConsoleSMSSender) SendSMS(ctx context.Context, to, body string) error {
+	s.logger.Printf("to=%s body=%q", to, body)
+	return nil
+}
+
+// FileSMSSender appends one line per message to a file, handy for tests that read the code back.
+type FileSMSSender struct {
+	path string
+	mu   sync.Mutex
+}
+
+func NewFileSMSSender(path string) (*FileSMSSender, error) {
+	if path == "" {
+		path = "logs/sms.log"
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, err
+	}
+	return &FileSMSSender{path: path}, nil
+}
+
+func (s *FileSMSSender) SendSMS(ctx context.Context, to, body string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "%s\t%s\t%s\n", time.Now().UTC().Format(time.RFC3339), to, body)
+	return err
+}
@@ -0,0 +1,205 @@
+/* Place: backend/go/repository/apikey_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"gatherup/models"
+
+	"github.com/google/uuid"
+)
+
+// APIKeyRepo stores hashed personal/bot API keys in dbo.api_keys.
+type APIKeyRepo struct {
+	db          *sql.DB
+	infoLogger  *log.Logger
+	errorLogger *log.Logger
+}
+
+// NewAPIKeyRepo constructs an APIKeyRepo. nil loggers fall back to the same defaults as NewUserRepo.
+func NewAPIKeyRepo(db *sql.DB, infoLogger, errorLogger *log.Logger) *APIKeyRepo {
+	if infoLogger == nil || errorLogger == nil {
+		dInfo, dErr := defaultLoggers()
+		if infoLogger == nil {
+			infoLogger = dInfo
+		}
+		if errorLogger == nil {
+			errorLogger = dErr
+		}
+	}
+	return &APIKeyRepo{db: db, infoLogger: infoLogger, errorLogger: errorLogger}
+}
+
+// Create inserts a key (k.ID is generated) with its hash.
+func (r *APIKeyRepo) Create(ctx context.Context, k *models.APIKey, keyHash string) error {
+	if _, err := uuid.Parse(k.UserID); err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+	k.ID = uuid.New().String()
+	k.CreatedAt = time.Now().UTC()
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO dbo.api_keys (id, user_id, name, key_prefix, key_hash, scopes, created_by, created_at, expires_at, is_revoked, is_deleted)
+        VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, @p9, 0, 0)
+    `, k.ID, k.UserID, k.Name, k.Prefix, keyHash, strings.Join(k.Scopes, " "), sqlNullString(k.CreatedBy), k.CreatedAt, k.ExpiresAt)
+	if err != nil {
+		r.errorLogger.Printf("APIKeyRepo.Create: insert failed userID=%s err=%v", k.UserID, err)
+		return err
+	}
+	r.infoLogger.Printf("APIKeyRepo.Create: id=%s userID=%s", k.ID, k.UserID)
+	return nil
+}
+
+// ListByUser returns the user's non-revoked keys, newest first (expired keys included).
+func (r *APIKeyRepo) ListByUser(ctx context.Context, userID string) ([]models.APIKey, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT CONVERT(nvarchar(36), id), name, key_prefix, scopes, CONVERT(nvarchar(36), created_by),
+               created_at, expires_at, last_used_at
+        FROM dbo.api_keys
+        WHERE user_id = @p1 AND is_revoked = 0 AND is_deleted = 0
+        ORDER BY created_at DESC
+    `, userID)
+	if err != nil {
+		r.errorLogger.Printf("APIKeyRepo.ListByUser: query failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.APIKey
+	for rows.Next() {
+		k := models.APIKey{UserID: userID}
+		var scopes string
+		var createdBy sql.NullString
+		var lastUsed sql.NullTime
+		if err := rows.Scan(&k.ID, &k.Name, &k.Prefix, &scopes, &createdBy, &k.CreatedAt, &k.ExpiresAt, &lastUsed); err != nil {
+			r.errorLogger.Printf("APIKeyRepo.ListByUser: scan failed userID=%s err=%v", userID, err)
+			return nil, err
+		}
+		k.Scopes = strings.Fields(scopes)
+		if createdBy.Valid {
+			k.CreatedBy = &createdBy.String
+		}
+		if lastUsed.Valid {
+			t := lastUsed.Time
+			k.LastUsedAt = &t
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+// CountActive returns how many unexpired, non-revoked keys the user has.
+func (r *APIKeyRepo) CountActive(ctx context.Context, userID string) (int, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return 0, fmt.Errorf("invalid user id: %w", err)
+	}
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM dbo.api_keys
+        WHERE user_id = @p1 AND is_revoked = 0 AND is_deleted = 0 AND expires_at > SYSDATETIMEOFFSET()
+    `, userID).Scan(&n)
+	if err != nil {
+		r.errorLogger.Printf("APIKeyRepo.CountActive: query failed userID=%s err=%v", userID, err)
+		return 0, err
+	}
+	return n, nil
+}
+
+// GetActiveByHash returns the usable key with keyHash: not revoked, not expired, and owned by
+// an active, non-deleted user. Returns nil, nil otherwise.
+func (r *APIKeyRepo) GetActiveByHash(ctx context.Context, keyHash string) (*models.APIKey, error) {
+	var k models.APIKey
+	var scopes string
+	var lastUsed sql.NullTime
+	row := r.db.QueryRowContext(ctx, `
+        SELECT CONVERT(nvarchar(36), k.id), CONVERT(nvarchar(36), k.user_id), k.name, k.key_prefix, k.scopes,
+               k.created_at, k.expires_at, k.last_used_at
+        FROM dbo.api_keys k
+        JOIN dbo.users u ON u.id = k.user_id AND u.is_deleted = 0 AND u.is_active = 1
+        WHERE k.key_hash = @p1 AND k.is_revoked = 0 AND k.is_deleted = 0 AND k.expires_at > SYSDATETIMEOFFSET()
+    `, keyHash)
+	if err := row.Scan(&k.ID, &k.UserID, &k.Name, &k.Prefix, &scopes, &k.CreatedAt, &k.ExpiresAt, &lastUsed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.errorLogger.Printf("APIKeyRepo.GetActiveByHash: scan failed err=%v", err)
+		return nil, err
+	}
+	k.Scopes = strings.Fields(scopes)
+	if lastUsed.Valid {
+		t := lastUsed.Time
+		k.LastUsedAt = &t
+	}
+	return &k, nil
+}
+
+// TouchLastUsed records use of a key.
+func (r *APIKeyRepo) TouchLastUsed(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.api_keys SET last_used_at = SYSDATETIMEOFFSET() WHERE id = @p1
+    `, id)
+	if err != nil {
+		r.errorLogger.Printf("APIKeyRepo.TouchLastUsed: exec failed id=%s err=%v", id, err)
+	}
+	return err
+}
+
+// Revoke revokes a key of userID. Returns false if no such live key exists.
+func (r *APIKeyRepo) Revoke(ctx context.Context, userID, id string) (bool, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	if _, err := uuid.Parse(id); err != nil {
+		return false, nil
+	}
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.api_keys SET is_revoked = 1, revoked_at = SYSDATETIMEOFFSET()
+        WHERE id = @p1 AND user_id = @p2 AND is_revoked = 0 AND is_deleted = 0
+    `, id, userID)
+	if err != nil {
+		r.errorLogger.Printf("APIKeyRepo.Revoke: exec failed id=%s err=%v", id, err)
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	if n > 0 {
+		r.infoLogger.Printf("APIKeyRepo.Revoke: id=%s userID=%s", id, userID)
+	}
+	return n > 0, nil
+}
+
+// RevokeAllForUser revokes every key of userID and of the bots userID owns, and returns how
+// many were revoked.
+func (r *APIKeyRepo) RevokeAllForUser(ctx context.Context, userID string) (int64, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return 0, fmt.Errorf("invalid user id: %w", err)
+	}
+	n, err := revokeAllAPIKeys(ctx, r.db, userID)
+	if err != nil {
+		r.errorLogger.Printf("APIKeyRepo.RevokeAllForUser: exec failed userID=%s err=%v", userID, err)
+		return 0, err
+	}
+	r.infoLogger.Printf("APIKeyRepo.RevokeAllForUser: userID=%s revoked=%d", userID, n)
+	return n, nil
+}
+
+// revokeAllAPIKeys is RevokeAllForUser inside the caller's transaction, so password changes,
+// logout-everywhere and deletion take the user's keys (bots included) down with their sessions.
+func revokeAllAPIKeys(ctx context.Context, ex execer, userID string) (int64, error) {
+	res, err := ex.ExecContext(ctx, `
+        UPDATE dbo.api_keys SET is_revoked = 1, revoked_at = SYSDATETIMEOFFSET()
+        WHERE is_revoked = 0 AND is_deleted = 0
+          AND (user_id = @p1 OR user_id IN (SELECT id FROM dbo.users WHERE bot_owner_id = @p1 AND is_bot = 1))
+    `, userID)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
@@ -0,0 +1,50 @@
+/* Place: backend/go/repository/audit_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"gatherup/models"
+)
+
+// AuditRepo writes security / admin events into dbo.audit_logs.
+type AuditRepo struct {
+	db          *sql.DB
+	infoLogger  *log.Logger
+	errorLogger *log.Logger
+}
+
+// NewAuditRepo constructs an AuditRepo. nil loggers fall back to the same defaults as NewUserRepo.
+func NewAuditRepo(db *sql.DB, infoLogger, errorLogger *log.Logger) *AuditRepo {
+	if infoLogger == nil || errorLogger == nil {
+		dInfo, dErr := defaultLoggers()
+		if infoLogger == nil {
+			infoLogger = dInfo
+		}
+		if errorLogger == nil {
+			errorLogger = dErr
+		}
+	}
+	return &AuditRepo{db: db, infoLogger: infoLogger, errorLogger: errorLogger}
+}
+
+// Insert appends an audit entry. CreatedAt defaults to now when zero.
+func (r *AuditRepo) Insert(ctx context.Context, a *models.AuditLog) error {
+	if a.CreatedAt.IsZero() {
+		a.CreatedAt = time.Now().UTC()
+	}
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO dbo.audit_logs (entity_type, entity_id, action, performed_by, user_agent, ip_address, payload, created_at, is_deleted)
+        VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, @p8, 0)
+    `, a.EntityType, a.EntityID, a.Action, sqlNullString(a.PerformedBy), sqlNullString(a.UserAgent), sqlNullString(a.IPAddress), sqlNullString(a.Payload), a.CreatedAt)
+	if err != nil {
+		r.errorLogger.Printf("AuditRepo.Insert: exec failed entity=%s/%s action=%s err=%v", a.EntityType, a.EntityID, a.Action, err)
+		return fmt.Errorf("insert audit log failed: %w", err)
+	}
+	r.infoLogger.Printf("AuditRepo.Insert: entity=%s/%s action=%s", a.EntityType, a.EntityID, a.Action)
+	return nil
+}
@@ -0,0 +1,174 @@
+/* Place: backend/go/repository/block_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"gatherup/models"
+
+	"github.com/google/uuid"
+)
+
+// BlockRepo manages dbo.blocks. Whether two users may interact is defined once, by the
+// dbo.user_blocks view (see blockedSQL); every query that returns or reaches other users
+// must filter with it.
+type BlockRepo struct {
+	db          *sql.DB
+	infoLogger  *log.Logger
+	errorLogger *log.Logger
+}
+
+// NewBlockRepo constructs a BlockRepo. nil loggers fall back to the same defaults as NewUserRepo.
+func NewBlockRepo(db *sql.DB, infoLogger, errorLogger *log.Logger) *BlockRepo {
+	if infoLogger == nil || errorLogger == nil {
+		dInfo, dErr := defaultLoggers()
+		if infoLogger == nil {
+			infoLogger = dInfo
+		}
+		if errorLogger == nil {
+			errorLogger = dErr
+		}
+	}
+	return &BlockRepo{db: db, infoLogger: infoLogger, errorLogger: errorLogger}
+}
+
+// blockedSQL returns a predicate that is true when either of the users a and b (SQL
+// expressions) blocked the other. Use "NOT "+blockedSQL(...) to filter results.
+func blockedSQL(a, b string) string {
+	return "EXISTS (SELECT 1 FROM dbo.user_blocks ub WHERE ub.user_id = " + a + " AND ub.other_user_id = " + b + ")"
+}
+
+// IsBlocked reports whether a block exists between a and b in either direction.
+func (r *BlockRepo) IsBlocked(ctx context.Context, a, b string) (bool, error) {
+	if _, err := uuid.Parse(a); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	if _, err := uuid.Parse(b); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	var blocked bool
+	err := r.db.QueryRowContext(ctx, `SELECT CASE WHEN `+blockedSQL("@p1", "@p2")+` THEN 1 ELSE 0 END`, a, b).Scan(&blocked)
+	if err != nil {
+		r.errorLogger.Printf("BlockRepo.IsBlocked: scan failed a=%s b=%s err=%v", a, b, err)
+		return false, err
+	}
+	return blocked, nil
+}
+
+// Block records that userID blocked targetID (reviving an earlier block of the pair) and, in
+// the same transaction, ends any contact or pending request between them. Blocking twice is
+// a no-op; created reports whether a new block was recorded.
+func (r *BlockRepo) Block(ctx context.Context, userID, targetID string, reason *string) (created bool, err error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("BlockRepo.Block: begin tx failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now().UTC()
+	res, err := tx.ExecContext(ctx, `
+        UPDATE dbo.blocks SET reason = @p3, created_at = @p4, is_deleted = 0, deleted_at = NULL
+        WHERE user_id = @p1 AND blocked_user_id = @p2 AND is_deleted = 1
+    `, userID, targetID, sqlNullString(reason), now)
+	if err != nil {
+		r.errorLogger.Printf("BlockRepo.Block: revive failed userID=%s target=%s err=%v", userID, targetID, err)
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 {
+		res, err = tx.ExecContext(ctx, `
+            INSERT INTO dbo.blocks (user_id, blocked_user_id, reason, created_at, is_deleted)
+            SELECT @p1, @p2, @p3, @p4, 0
+            WHERE NOT EXISTS (SELECT 1 FROM dbo.blocks WHERE user_id = @p1 AND blocked_user_id = @p2)
+        `, userID, targetID, sqlNullString(reason), now)
+		if err != nil {
+			if isUniqueViolation(err) {
+				return false, nil
+			}
+			r.errorLogger.Printf("BlockRepo.Block: insert failed userID=%s target=%s err=%v", userID, targetID, err)
+			return false, err
+		}
+		n, _ = res.RowsAffected()
+	}
+	if _, err := tx.ExecContext(ctx, `
+        UPDATE dbo.contacts SET is_deleted = 1, deleted_at = @p3
+        WHERE is_deleted = 0 AND ((user_id = @p1 AND contact_user_id = @p2) OR (user_id = @p2 AND contact_user_id = @p1))
+    `, userID, targetID, now); err != nil {
+		r.errorLogger.Printf("BlockRepo.Block: contacts cleanup failed userID=%s target=%s err=%v", userID, targetID, err)
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("BlockRepo.Block: commit failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	r.infoLogger.Printf("BlockRepo.Block: userID=%s target=%s created=%v", userID, targetID, n > 0)
+	return n > 0, nil
+}
+
+// Unblock lifts userID's block of targetID. Returns false if there was none.
+func (r *BlockRepo) Unblock(ctx context.Context, userID, targetID string) (bool, error) {
+	if _, err := uuid.Parse(targetID); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.blocks SET is_deleted = 1, deleted_at = SYSDATETIMEOFFSET()
+        WHERE user_id = @p1 AND blocked_user_id = @p2 AND is_deleted = 0
+    `, userID, targetID)
+	if err != nil {
+		r.errorLogger.Printf("BlockRepo.Unblock: update failed userID=%s target=%s err=%v", userID, targetID, err)
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	r.infoLogger.Printf("BlockRepo.Unblock: userID=%s target=%s updated=%d", userID, targetID, n)
+	return n > 0, nil
+}
+
+// BlockListItem is a BlockedUser with its row id, which pages the list.
+type BlockListItem struct {
+	models.BlockedUser
+	RowID int64
+}
+
+// List returns the users userID blocked, newest first, after row id afterID (0 for the first page).
+func (r *BlockRepo) List(ctx context.Context, userID string, afterID int64, limit int) ([]BlockListItem, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT TOP (@p3) b.id, b.reason, b.created_at,
+               CONVERT(nvarchar(36), u.id), u.username, u.display_name, u.avatar_url, u.is_bot
+        FROM dbo.blocks b
+        JOIN dbo.users u ON u.id = b.blocked_user_id
+        WHERE b.user_id = @p1 AND b.is_deleted = 0 AND (@p2 = 0 OR b.id < @p2) AND u.is_deleted = 0
+        ORDER BY b.id DESC
+    `, userID, afterID, limit)
+	if err != nil {
+		r.errorLogger.Printf("BlockRepo.List: query failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []BlockListItem
+	for rows.Next() {
+		var it BlockListItem
+		var reason, username, displayName, avatarURL sql.NullString
+		if err := rows.Scan(&it.RowID, &reason, &it.BlockedAt,
+			&it.User.ID, &username, &displayName, &avatarURL, &it.User.IsBot); err != nil {
+			r.errorLogger.Printf("BlockRepo.List: scan failed userID=%s err=%v", userID, err)
+			return nil, err
+		}
+		it.Reason = nullStringPtr(reason)
+		it.User.Username = nullStringPtr(username)
+		it.User.DisplayName = nullStringPtr(displayName)
+		it.User.AvatarURL = nullStringPtr(avatarURL)
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
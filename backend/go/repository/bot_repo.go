@@ -0,0 +1,102 @@
+/* Place: backend/go/repository/bot_repo.go */
+package repository
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"gatherup/models"
+
+	"github.com/google/uuid"
+)
+
+// CreateBotUser inserts a bot account owned by ownerID. Bots have no credentials; the
+// required mobile columns get a unique "bot:" placeholder that can never be verified or matched.
+// Returns ErrDuplicate if username is taken.
+func (r *UserRepo) CreateBotUser(ctx context.Context, ownerID string, displayName, username *string) (string, error) {
+	if _, err := uuid.Parse(ownerID); err != nil {
+		return "", fmt.Errorf("invalid owner id: %w", err)
+	}
+	b := make([]byte, 10)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	placeholder := "bot:" + hex.EncodeToString(b)
+
+	id := uuid.New().String()
+	now := time.Now().UTC()
+	_, err := r.db.ExecContext(ctx, `
+        INSERT INTO dbo.users (id, mobile_number, mobile_normalized, display_name, username, is_bot, bot_owner_id, created_at, is_deleted)
+        VALUES (@p1, @p2, @p2, @p3, @p4, 1, @p5, @p6, 0)
+    `, id, placeholder, sqlNullString(displayName), sqlNullString(username), ownerID, now)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return "", ErrDuplicate
+		}
+		r.errorLogger.Printf("CreateBotUser: insert failed ownerID=%s err=%v", ownerID, err)
+		return "", err
+	}
+	r.infoLogger.Printf("CreateBotUser: created id=%s ownerID=%s", id, ownerID)
+	return id, nil
+}
+
+// ListBots returns the bot accounts owned by ownerID.
+func (r *UserRepo) ListBots(ctx context.Context, ownerID string) ([]models.User, error) {
+	if _, err := uuid.Parse(ownerID); err != nil {
+		return nil, fmt.Errorf("invalid owner id: %w", err)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT CONVERT(nvarchar(36), id), display_name, username, created_at
+        FROM dbo.users
+        WHERE bot_owner_id = @p1 AND is_bot = 1 AND is_deleted = 0
+        ORDER BY created_at
+    `, ownerID)
+	if err != nil {
+		r.errorLogger.Printf("ListBots: query failed ownerID=%s err=%v", ownerID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.User
+	for rows.Next() {
+		u := models.User{IsBot: true, BotOwnerID: &ownerID, IsActive: true}
+		var displayName, username sql.NullString
+		if err := rows.Scan(&u.ID, &displayName, &username, &u.CreatedAt); err != nil {
+			r.errorLogger.Printf("ListBots: scan failed ownerID=%s err=%v", ownerID, err)
+			return nil, err
+		}
+		if displayName.Valid {
+			u.DisplayName = &displayName.String
+		}
+		if username.Valid {
+			u.Username = &username.String
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+// GetBotOwner returns the owner of bot botID ("" if botID is not a live bot).
+func (r *UserRepo) GetBotOwner(ctx context.Context, botID string) (string, error) {
+	if _, err := uuid.Parse(botID); err != nil {
+		return "", nil
+	}
+	var owner sql.NullString
+	row := r.db.QueryRowContext(ctx, `
+        SELECT CONVERT(nvarchar(36), bot_owner_id) FROM dbo.users
+        WHERE id = @p1 AND is_bot = 1 AND is_deleted = 0
+    `, botID)
+	if err := row.Scan(&owner); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		r.errorLogger.Printf("GetBotOwner: scan failed botID=%s err=%v", botID, err)
+		return "", err
+	}
+	return owner.String, nil
+}
@@ -0,0 +1,306 @@
+/* Place: backend/go/repository/contact_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gatherup/models"
+
+	"github.com/google/uuid"
+)
+
+// ContactRepo manages contact requests and contacts in dbo.contacts. Whether two users are
+// contacts is defined once, by the dbo.user_contacts view (see isContactSQL).
+type ContactRepo struct {
+	db          *sql.DB
+	infoLogger  *log.Logger
+	errorLogger *log.Logger
+}
+
+// NewContactRepo constructs a ContactRepo. nil loggers fall back to the same defaults as NewUserRepo.
+func NewContactRepo(db *sql.DB, infoLogger, errorLogger *log.Logger) *ContactRepo {
+	if infoLogger == nil || errorLogger == nil {
+		dInfo, dErr := defaultLoggers()
+		if infoLogger == nil {
+			infoLogger = dInfo
+		}
+		if errorLogger == nil {
+			errorLogger = dErr
+		}
+	}
+	return &ContactRepo{db: db, infoLogger: infoLogger, errorLogger: errorLogger}
+}
+
+// isContactSQL returns a predicate that is true when the users a and b (SQL expressions) are
+// accepted contacts. Every query that depends on the relationship must use it.
+func isContactSQL(a, b string) string {
+	return "EXISTS (SELECT 1 FROM dbo.user_contacts uc WHERE uc.user_id = " + a + " AND uc.contact_user_id = " + b + ")"
+}
+
+// GetPair returns the live contact row between a and b in either direction, or nil, nil.
+func (r *ContactRepo) GetPair(ctx context.Context, a, b string) (*models.Contact, error) {
+	if _, err := uuid.Parse(a); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	if _, err := uuid.Parse(b); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	var c models.Contact
+	var accepted sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+        SELECT TOP 1 id, CONVERT(nvarchar(36), user_id), CONVERT(nvarchar(36), contact_user_id), status, created_at, accepted_at
+        FROM dbo.contacts
+        WHERE is_deleted = 0 AND ((user_id = @p1 AND contact_user_id = @p2) OR (user_id = @p2 AND contact_user_id = @p1))
+        ORDER BY CASE status WHEN 'accepted' THEN 0 ELSE 1 END
+    `, a, b).Scan(&c.ID, &c.UserID, &c.ContactUserID, &c.Status, &c.CreatedAt, &accepted)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.errorLogger.Printf("ContactRepo.GetPair: scan failed a=%s b=%s err=%v", a, b, err)
+		return nil, err
+	}
+	c.AcceptedAt = nullTimePtr(accepted)
+	return &c, nil
+}
+
+// CreateRequest records a pending request from -> to, reviving an earlier cancelled or
+// declined row of the same pair, and writes notify in the same transaction.
+// Returns ErrDuplicate if a live row already exists.
+func (r *ContactRepo) CreateRequest(ctx context.Context, from, to string, notify *models.Notification) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("ContactRepo.CreateRequest: begin tx failed from=%s err=%v", from, err)
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now().UTC()
+	res, err := tx.ExecContext(ctx, `
+        UPDATE dbo.contacts
+        SET status = 'pending', relation_type = 'friend', created_at = @p3, accepted_at = NULL, is_deleted = 0, deleted_at = NULL
+        WHERE user_id = @p1 AND contact_user_id = @p2 AND is_deleted = 1
+    `, from, to, now)
+	if err != nil {
+		r.errorLogger.Printf("ContactRepo.CreateRequest: revive failed from=%s to=%s err=%v", from, to, err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO dbo.contacts (user_id, contact_user_id, relation_type, status, created_at, is_deleted)
+            VALUES (@p1, @p2, 'friend', 'pending', @p3, 0)
+        `, from, to, now); err != nil {
+			if isUniqueViolation(err) {
+				return ErrDuplicate
+			}
+			r.errorLogger.Printf("ContactRepo.CreateRequest: insert failed from=%s to=%s err=%v", from, to, err)
+			return err
+		}
+	}
+	if err := insertNotification(ctx, tx, notify); err != nil {
+		r.errorLogger.Printf("ContactRepo.CreateRequest: notification failed from=%s to=%s err=%v", from, to, err)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("ContactRepo.CreateRequest: commit failed from=%s err=%v", from, err)
+		return err
+	}
+	r.infoLogger.Printf("ContactRepo.CreateRequest: from=%s to=%s", from, to)
+	return nil
+}
+
+// Accept accepts the pending request requester -> recipient and writes notify in the same
+// transaction. Returns false if there is no such request.
+func (r *ContactRepo) Accept(ctx context.Context, requester, recipient string, notify *models.Notification) (bool, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("ContactRepo.Accept: begin tx failed recipient=%s err=%v", recipient, err)
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+        UPDATE dbo.contacts SET status = 'accepted', accepted_at = SYSDATETIMEOFFSET()
+        WHERE user_id = @p1 AND contact_user_id = @p2 AND status = 'pending' AND is_deleted = 0
+    `, requester, recipient)
+	if err != nil {
+		r.errorLogger.Printf("ContactRepo.Accept: update failed requester=%s recipient=%s err=%v", requester, recipient, err)
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if err := insertNotification(ctx, tx, notify); err != nil {
+		r.errorLogger.Printf("ContactRepo.Accept: notification failed requester=%s err=%v", requester, err)
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("ContactRepo.Accept: commit failed recipient=%s err=%v", recipient, err)
+		return false, err
+	}
+	r.infoLogger.Printf("ContactRepo.Accept: requester=%s recipient=%s", requester, recipient)
+	return true, nil
+}
+
+// Decline rejects the pending request requester -> recipient. The requester is not told and
+// may ask again later. Returns false if there is no such request.
+func (r *ContactRepo) Decline(ctx context.Context, requester, recipient string) (bool, error) {
+	return r.closeRows(ctx, "Decline", `
+        UPDATE dbo.contacts SET status = 'declined', is_deleted = 1, deleted_at = SYSDATETIMEOFFSET()
+        WHERE user_id = @p1 AND contact_user_id = @p2 AND status = 'pending' AND is_deleted = 0
+    `, requester, recipient)
+}
+
+// Cancel withdraws the pending request requester -> recipient.
+func (r *ContactRepo) Cancel(ctx context.Context, requester, recipient string) (bool, error) {
+	return r.closeRows(ctx, "Cancel", `
+        UPDATE dbo.contacts SET is_deleted = 1, deleted_at = SYSDATETIMEOFFSET()
+        WHERE user_id = @p1 AND contact_user_id = @p2 AND status = 'pending' AND is_deleted = 0
+    `, requester, recipient)
+}
+
+// Remove ends the accepted contact between a and b.
+func (r *ContactRepo) Remove(ctx context.Context, a, b string) (bool, error) {
+	return r.closeRows(ctx, "Remove", `
+        UPDATE dbo.contacts SET is_deleted = 1, deleted_at = SYSDATETIMEOFFSET()
+        WHERE status = 'accepted' AND is_deleted = 0
+          AND ((user_id = @p1 AND contact_user_id = @p2) OR (user_id = @p2 AND contact_user_id = @p1))
+    `, a, b)
+}
+
+func (r *ContactRepo) closeRows(ctx context.Context, op, query, a, b string) (bool, error) {
+	if _, err := uuid.Parse(a); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	if _, err := uuid.Parse(b); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	res, err := r.db.ExecContext(ctx, query, a, b)
+	if err != nil {
+		r.errorLogger.Printf("ContactRepo.%s: update failed a=%s b=%s err=%v", op, a, b, err)
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	r.infoLogger.Printf("ContactRepo.%s: a=%s b=%s updated=%d", op, a, b, n)
+	return n > 0, nil
+}
+
+// CountPendingOutgoing returns how many unanswered requests userID has sent.
+func (r *ContactRepo) CountPendingOutgoing(ctx context.Context, userID string) (int, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(1) FROM dbo.contacts WHERE user_id = @p1 AND status = 'pending' AND is_deleted = 0
+    `, userID).Scan(&n)
+	if err != nil {
+		r.errorLogger.Printf("ContactRepo.CountPendingOutgoing: query failed userID=%s err=%v", userID, err)
+	}
+	return n, err
+}
+
+// ContactListItem is a ContactEntry with its row id, which pages the list.
+type ContactListItem struct {
+	models.ContactEntry
+	RowID int64
+}
+
+// List returns userID's contacts (models.ContactAccepted) or pending requests
+// (models.ContactIncoming / models.ContactOutgoing), newest first, after row id afterID (0 for
+// the first page).
+func (r *ContactRepo) List(ctx context.Context, userID, filter string, afterID int64, limit int) ([]ContactListItem, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	var where string
+	switch filter {
+	case models.ContactAccepted:
+		where = "c.status = 'accepted' AND (c.user_id = @p1 OR c.contact_user_id = @p1)"
+	case models.ContactIncoming:
+		where = "c.status = 'pending' AND c.contact_user_id = @p1"
+	case models.ContactOutgoing:
+		where = "c.status = 'pending' AND c.user_id = @p1"
+	default:
+		return nil, fmt.Errorf("unknown contact filter %q", filter)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT TOP (@p3) c.id, c.status, CASE WHEN c.user_id = @p1 THEN 'outgoing' ELSE 'incoming' END, c.created_at, c.accepted_at,
+               CONVERT(nvarchar(36), u.id), u.username, u.display_name, u.avatar_url, u.is_bot
+        FROM dbo.contacts c
+        JOIN dbo.users u ON u.id = CASE WHEN c.user_id = @p1 THEN c.contact_user_id ELSE c.user_id END
+        WHERE c.is_deleted = 0 AND `+where+` AND (@p2 = 0 OR c.id < @p2)
+          AND u.is_deleted = 0 AND u.is_active = 1 AND NOT `+blockedSQL("@p1", "u.id")+`
+        ORDER BY c.id DESC
+    `, userID, afterID, limit)
+	if err != nil {
+		r.errorLogger.Printf("ContactRepo.List: query failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []ContactListItem
+	for rows.Next() {
+		var it ContactListItem
+		var accepted sql.NullTime
+		var username, displayName, avatarURL sql.NullString
+		if err := rows.Scan(&it.RowID, &it.Status, &it.Direction, &it.CreatedAt, &accepted,
+			&it.User.ID, &username, &displayName, &avatarURL, &it.User.IsBot); err != nil {
+			r.errorLogger.Printf("ContactRepo.List: scan failed userID=%s err=%v", userID, err)
+			return nil, err
+		}
+		it.AcceptedAt = nullTimePtr(accepted)
+		it.User.Username = nullStringPtr(username)
+		it.User.DisplayName = nullStringPtr(displayName)
+		it.User.AvatarURL = nullStringPtr(avatarURL)
+		it.User.IsContact = it.Status == models.ContactAccepted
+		out = append(out, it)
+	}
+	return out, rows.Err()
+}
+
+// ListMutual returns up to limit users who are contacts of both a and b.
+func (r *ContactRepo) ListMutual(ctx context.Context, a, b string, limit int) ([]models.UserSummary, error) {
+	if _, err := uuid.Parse(a); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	if _, err := uuid.Parse(b); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT TOP (@p3) CONVERT(nvarchar(36), u.id), u.username, u.display_name, u.avatar_url, u.is_bot
+        FROM dbo.user_contacts x
+        JOIN dbo.user_contacts y ON y.contact_user_id = x.contact_user_id AND y.user_id = @p2
+        JOIN dbo.users u ON u.id = x.contact_user_id
+        WHERE x.user_id = @p1 AND u.is_deleted = 0 AND u.is_active = 1
+          AND NOT `+blockedSQL("@p1", "u.id")+` AND NOT `+blockedSQL("@p2", "u.id")+`
+        ORDER BY COALESCE(u.display_name, u.username)
+    `, a, b, limit)
+	if err != nil {
+		r.errorLogger.Printf("ContactRepo.ListMutual: query failed a=%s b=%s err=%v", a, b, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.UserSummary{}
+	for rows.Next() {
+		var s models.UserSummary
+		var username, displayName, avatarURL sql.NullString
+		if err := rows.Scan(&s.ID, &username, &displayName, &avatarURL, &s.IsBot); err != nil {
+			r.errorLogger.Printf("ContactRepo.ListMutual: scan failed a=%s err=%v", a, err)
+			return nil, err
+		}
+		s.Username = nullStringPtr(username)
+		s.DisplayName = nullStringPtr(displayName)
+		s.AvatarURL = nullStringPtr(avatarURL)
+		s.IsContact = true
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
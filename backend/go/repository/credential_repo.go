@@ -0,0 +1,188 @@
+/* Place: backend/go/repository/credential_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"gatherup/models"
+
+	"github.com/google/uuid"
+)
+
+// Credential types stored in dbo.user_credentials.
+const (
+	// CredentialPassword holds the password hash; its identifier is the normalized mobile number.
+	CredentialPassword = "password"
+	// CredentialEmail links a verified email address as a login identifier.
+	CredentialEmail = "email"
+	// CredentialTOTP holds the encrypted TOTP secret of an account with 2FA enabled.
+	CredentialTOTP = "totp"
+	// CredentialTOTPPending is a TOTP secret that was enrolled but not yet confirmed with a code.
+	CredentialTOTPPending = "totp_pending"
+	// CredentialRecoveryCode is one hashed single-use 2FA recovery code.
+	CredentialRecoveryCode = "recovery_code"
+)
+
+// ListCredentials returns the user's credentials (no secrets), oldest first.
+func (r *UserRepo) ListCredentials(ctx context.Context, userID string) ([]models.Credential, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT id, credential_type, credential_identifier, created_at, last_used
+        FROM dbo.user_credentials
+        WHERE user_id = @p1 AND is_deleted = 0
+        ORDER BY created_at
+    `, userID)
+	if err != nil {
+		r.errorLogger.Printf("ListCredentials: query failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Credential
+	for rows.Next() {
+		c := models.Credential{UserID: userID}
+		var ident sql.NullString
+		var lastUsed sql.NullTime
+		if err := rows.Scan(&c.ID, &c.Type, &ident, &c.CreatedAt, &lastUsed); err != nil {
+			r.errorLogger.Printf("ListCredentials: scan failed userID=%s err=%v", userID, err)
+			return nil, err
+		}
+		if ident.Valid {
+			c.Identifier = &ident.String
+		}
+		if lastUsed.Valid {
+			t := lastUsed.Time
+			c.LastUsed = &t
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// GetUserIDByCredential resolves a login identifier of the given type to a user id ("" if none).
+func (r *UserRepo) GetUserIDByCredential(ctx context.Context, credentialType, identifier string) (string, error) {
+	var uid sql.NullString
+	row := r.db.QueryRowContext(ctx, `
+        SELECT CONVERT(nvarchar(36), c.user_id)
+        FROM dbo.user_credentials c
+        JOIN dbo.users u ON u.id = c.user_id AND u.is_deleted = 0
+        WHERE c.credential_type = @p1 AND c.credential_identifier = @p2 AND c.is_deleted = 0
+    `, credentialType, identifier)
+	if err := row.Scan(&uid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		r.errorLogger.Printf("GetUserIDByCredential: scan failed type=%s err=%v", credentialType, err)
+		return "", err
+	}
+	return uid.String, nil
+}
+
+// GetUserIDByUsername resolves a username (case-insensitive per column collation) to a user id.
+func (r *UserRepo) GetUserIDByUsername(ctx context.Context, username string) (string, error) {
+	var uid sql.NullString
+	row := r.db.QueryRowContext(ctx, `
+        SELECT CONVERT(nvarchar(36), id) FROM dbo.users
+        WHERE username = @p1 AND is_deleted = 0
+    `, username)
+	if err := row.Scan(&uid); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		r.errorLogger.Printf("GetUserIDByUsername: scan failed err=%v", err)
+		return "", err
+	}
+	return uid.String, nil
+}
+
+// AddVerifiedEmail links a verified email: inserts the email credential and sets users.email.
+// Returns ErrDuplicate if another account already uses the address.
+func (r *UserRepo) AddVerifiedEmail(ctx context.Context, userID, email string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("AddVerifiedEmail: begin tx failed userID=%s err=%v", userID, err)
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now().UTC()
+	if _, err = tx.ExecContext(ctx, `
+        INSERT INTO dbo.user_credentials (user_id, credential_type, credential_identifier, created_at, is_deleted)
+        VALUES (@p1, @p2, @p3, @p4, 0)
+    `, userID, CredentialEmail, email, now); err != nil {
+		if isUniqueViolation(err) {
+			return ErrDuplicate
+		}
+		r.errorLogger.Printf("AddVerifiedEmail: insert credential failed userID=%s err=%v", userID, err)
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `
+        UPDATE dbo.users SET email = @p2, is_email_verified = 1, updated_at = @p3
+        WHERE id = @p1 AND is_deleted = 0
+    `, userID, email, now); err != nil {
+		r.errorLogger.Printf("AddVerifiedEmail: update user failed userID=%s err=%v", userID, err)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("AddVerifiedEmail: commit failed userID=%s err=%v", userID, err)
+		return err
+	}
+	r.infoLogger.Printf("AddVerifiedEmail: linked userID=%s", userID)
+	return nil
+}
+
+// DeleteCredential soft-deletes one of the user's credentials. Removing the email credential
+// also clears users.email. Returns false if the credential doesn't belong to the user.
+func (r *UserRepo) DeleteCredential(ctx context.Context, userID string, credentialID int64) (bool, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("DeleteCredential: begin tx failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	var ctype string
+	row := tx.QueryRowContext(ctx, `
+        UPDATE dbo.user_credentials SET is_deleted = 1, deleted_at = SYSDATETIMEOFFSET()
+        OUTPUT deleted.credential_type
+        WHERE id = @p1 AND user_id = @p2 AND is_deleted = 0
+    `, credentialID, userID)
+	if err := row.Scan(&ctype); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		r.errorLogger.Printf("DeleteCredential: update failed id=%d userID=%s err=%v", credentialID, userID, err)
+		return false, err
+	}
+	if ctype == CredentialEmail {
+		if _, err := tx.ExecContext(ctx, `
+            UPDATE dbo.users SET email = NULL, is_email_verified = 0, updated_at = SYSDATETIMEOFFSET()
+            WHERE id = @p1
+        `, userID); err != nil {
+			r.errorLogger.Printf("DeleteCredential: clear email failed userID=%s err=%v", userID, err)
+			return false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("DeleteCredential: commit failed id=%d userID=%s err=%v", credentialID, userID, err)
+		return false, err
+	}
+	r.infoLogger.Printf("DeleteCredential: id=%d type=%s userID=%s", credentialID, ctype, userID)
+	return true, nil
+}
@@ -0,0 +1,217 @@
+/* Place: backend/go/repository/deletion_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ScheduleDeletion starts the deletion grace period: the account is deactivated (hidden) and
+// every refresh token, session and API key of the user and their bots is revoked. Returns false if the user
+// doesn't exist or a deletion is already scheduled.
+func (r *UserRepo) ScheduleDeletion(ctx context.Context, userID string, scheduledAt time.Time) (bool, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("ScheduleDeletion: begin tx failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now().UTC()
+	res, err := tx.ExecContext(ctx, `
+        UPDATE dbo.users
+        SET deletion_requested_at = @p2, deletion_scheduled_at = @p3, is_active = 0, updated_at = @p2
+        WHERE id = @p1 AND is_deleted = 0 AND deletion_scheduled_at IS NULL
+    `, userID, now, scheduledAt)
+	if err != nil {
+		r.errorLogger.Printf("ScheduleDeletion: update user failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if _, err := revokeAllSessions(ctx, tx, userID); err != nil {
+		r.errorLogger.Printf("ScheduleDeletion: revoke sessions failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	if _, err := revokeAllAPIKeys(ctx, tx, userID); err != nil {
+		r.errorLogger.Printf("ScheduleDeletion: revoke api keys failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("ScheduleDeletion: commit failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	r.infoLogger.Printf("ScheduleDeletion: userID=%s scheduled_at=%s", userID, scheduledAt.Format(time.RFC3339))
+	return true, nil
+}
+
+// GetDeletionSchedule returns when the user's pending deletion runs (nil if none is pending).
+func (r *UserRepo) GetDeletionSchedule(ctx context.Context, userID string) (*time.Time, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	var at sql.NullTime
+	row := r.db.QueryRowContext(ctx, `
+        SELECT deletion_scheduled_at FROM dbo.users WHERE id = @p1 AND is_deleted = 0
+    `, userID)
+	if err := row.Scan(&at); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.errorLogger.Printf("GetDeletionSchedule: scan failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	if !at.Valid {
+		return nil, nil
+	}
+	t := at.Time
+	return &t, nil
+}
+
+// CancelDeletion clears a pending deletion that has not run yet and reactivates the account.
+// Returns false if there was nothing to cancel.
+func (r *UserRepo) CancelDeletion(ctx context.Context, userID string) (bool, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.users
+        SET deletion_requested_at = NULL, deletion_scheduled_at = NULL, is_active = 1, updated_at = SYSDATETIMEOFFSET()
+        WHERE id = @p1 AND is_deleted = 0 AND deletion_scheduled_at > SYSDATETIMEOFFSET()
+    `, userID)
+	if err != nil {
+		r.errorLogger.Printf("CancelDeletion: update failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	if n > 0 {
+		r.infoLogger.Printf("CancelDeletion: userID=%s", userID)
+	}
+	return n > 0, nil
+}
+
+// ListDueDeletions returns up to limit users whose grace period ended before now.
+func (r *UserRepo) ListDueDeletions(ctx context.Context, now time.Time, limit int) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT TOP (@p2) CONVERT(nvarchar(36), id) FROM dbo.users
+        WHERE deletion_scheduled_at IS NOT NULL AND deletion_scheduled_at <= @p1 AND is_deleted = 0
+        ORDER BY deletion_scheduled_at
+    `, now, limit)
+	if err != nil {
+		r.errorLogger.Printf("ListDueDeletions: query failed err=%v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			r.errorLogger.Printf("ListDueDeletions: scan failed err=%v", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// purgeStatements run in order inside PurgeUser; @p1 is the user id, @p2 the purge time.
+var purgeStatements = []struct{ name, sql string }{
+	{"users", `
+        UPDATE dbo.users SET
+            mobile_number = CONCAT('deleted:', LEFT(REPLACE(CONVERT(nvarchar(36), id), '-', ''), 24)),
+            mobile_normalized = CONCAT('del:', LEFT(REPLACE(CONVERT(nvarchar(36), id), '-', ''), 20)),
+            country_code = NULL, display_name = NULL, avatar_url = NULL, bio = NULL, email = NULL, username = NULL, username_changed_at = NULL,
+            latitude = NULL, longitude = NULL, location = NULL, location_updated_at = NULL,
+            date_of_birth = NULL, gender = NULL,
+            is_mobile_verified = 0, mobile_verified_at = NULL, mobile_lookup_hash = NULL, is_email_verified = 0, is_active = 0,
+            updated_at = @p2, is_deleted = 1, deleted_at = @p2
+        WHERE id = @p1`},
+	{"credentials", `
+        UPDATE dbo.user_credentials
+        SET credential_identifier = NULL, password_hash = NULL, salt = NULL, is_deleted = 1, deleted_at = COALESCE(deleted_at, @p2)
+        WHERE user_id = @p1`},
+	{"roles", `UPDATE dbo.user_roles SET is_deleted = 1, deleted_at = @p2 WHERE user_id = @p1 AND is_deleted = 0`},
+	{"refresh_tokens", `UPDATE dbo.refresh_tokens SET is_revoked = 1, device_info = NULL WHERE user_id = @p1`},
+	{"sessions", `
+        UPDATE dbo.user_sessions
+        SET is_revoked = 1, device_info = NULL, ip_address = NULL, session_latitude = NULL, session_longitude = NULL,
+            session_location = NULL, is_deleted = 1, deleted_at = COALESCE(deleted_at, @p2)
+        WHERE user_id = @p1`},
+	{"api_keys", `
+        UPDATE dbo.api_keys SET is_revoked = 1, revoked_at = COALESCE(revoked_at, @p2), is_deleted = 1, deleted_at = @p2
+        WHERE is_deleted = 0 AND (user_id = @p1 OR user_id IN (SELECT id FROM dbo.users WHERE bot_owner_id = @p1))`},
+	{"bots", `UPDATE dbo.users SET is_active = 0, is_deleted = 1, deleted_at = @p2 WHERE bot_owner_id = @p1 AND is_deleted = 0`},
+	{"otp_codes", `UPDATE dbo.otp_codes SET is_deleted = 1, deleted_at = @p2 WHERE user_id = @p1 AND is_deleted = 0`},
+	{"devices", `
+        UPDATE dbo.user_devices SET push_token = NULL, device_uuid = NULL, is_active = 0, is_deleted = 1, deleted_at = @p2
+        WHERE user_id = @p1 AND is_deleted = 0`},
+	{"preferences", `UPDATE dbo.user_preferences SET is_deleted = 1, deleted_at = @p2 WHERE user_id = @p1 AND is_deleted = 0`},
+	{"skills", `UPDATE dbo.user_skills SET is_deleted = 1, deleted_at = @p2 WHERE user_id = @p1 AND is_deleted = 0`},
+	{"contacts", `
+        UPDATE dbo.contacts SET is_deleted = 1, deleted_at = @p2
+        WHERE (user_id = @p1 OR contact_user_id = @p1) AND is_deleted = 0`},
+	{"blocks", `
+        UPDATE dbo.blocks SET is_deleted = 1, deleted_at = @p2
+        WHERE (user_id = @p1 OR blocked_user_id = @p1) AND is_deleted = 0`},
+	{"posts", `UPDATE dbo.posts SET is_deleted = 1, deleted_at = @p2 WHERE author_id = @p1 AND is_deleted = 0`},
+	{"comments", `UPDATE dbo.comments SET is_deleted = 1, deleted_at = @p2 WHERE author_id = @p1 AND is_deleted = 0`},
+	{"messages", `UPDATE dbo.messages SET is_deleted = 1, deleted_at = @p2 WHERE sender_id = @p1 AND is_deleted = 0`},
+	// archives are removed from storage by the export processor once expires_at passes
+	{"data_exports", `UPDATE dbo.data_exports SET expires_at = @p2 WHERE user_id = @p1 AND is_deleted = 0`},
+	{"notifications", `UPDATE dbo.notifications SET is_deleted = 1, deleted_at = @p2 WHERE user_id = @p1 AND is_deleted = 0`},
+}
+
+// PurgeUser anonymizes a user whose deletion grace period has ended, soft-deletes their
+// content and removes their credentials, all in one transaction. Returns false (and changes
+// nothing) if the deletion was cancelled or isn't due yet.
+func (r *UserRepo) PurgeUser(ctx context.Context, userID string) (bool, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("PurgeUser: begin tx failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now().UTC()
+	// lock the row so a concurrent CancelDeletion either wins before us or waits
+	var due int
+	if err := tx.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM dbo.users WITH (UPDLOCK, HOLDLOCK)
+        WHERE id = @p1 AND is_deleted = 0 AND deletion_scheduled_at <= @p2
+    `, userID, now).Scan(&due); err != nil {
+		r.errorLogger.Printf("PurgeUser: lock failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	if due == 0 {
+		return false, nil
+	}
+	for _, st := range purgeStatements {
+		if _, err := tx.ExecContext(ctx, st.sql, userID, now); err != nil {
+			r.errorLogger.Printf("PurgeUser: %s failed userID=%s err=%v", st.name, userID, err)
+			return false, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("PurgeUser: commit failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	r.infoLogger.Printf("PurgeUser: purged userID=%s", userID)
+	return true, nil
+}
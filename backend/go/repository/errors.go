@@ -0,0 +1,26 @@
+/* Place: backend/go/repository/errors.go */
+package repository
+
+import (
+	"errors"
+
+	mssql "github.com/denisenkom/go-mssqldb"
+)
+
+// ErrDuplicate is returned when an insert/update hits a unique index or constraint.
+var ErrDuplicate = errors.New("duplicate key")
+
+// ErrStale is returned when a conditional update's expected rowversion no longer matches.
+var ErrStale = errors.New("row version mismatch")
+
+// ErrUsernameCooldown is returned by UpdateProfile when the username was changed after the cutoff.
+var ErrUsernameCooldown = errors.New("username changed too recently")
+
+// isUniqueViolation reports SQL Server duplicate key errors (2601 unique index, 2627 unique constraint).
+func isUniqueViolation(err error) bool {
+	var me mssql.Error
+	if errors.As(err, &me) {
+		return me.Number == 2601 || me.Number == 2627
+	}
+	return false
+}
@@ -0,0 +1,313 @@
+/* Place: backend/go/repository/export_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gatherup/models"
+
+	"github.com/google/uuid"
+)
+
+// JobTopicUserExport is the dbo.jobs topic of data export jobs.
+const JobTopicUserExport = "user_export"
+
+// ExportJobPayload is the JSON payload of a JobTopicUserExport job.
+type ExportJobPayload struct {
+	ExportID string `json:"export_id"`
+}
+
+// ExportRepo manages dbo.data_exports and reads the user data that goes into an export.
+type ExportRepo struct {
+	db          *sql.DB
+	infoLogger  *log.Logger
+	errorLogger *log.Logger
+}
+
+// NewExportRepo constructs an ExportRepo. nil loggers fall back to the same defaults as NewUserRepo.
+func NewExportRepo(db *sql.DB, infoLogger, errorLogger *log.Logger) *ExportRepo {
+	if infoLogger == nil || errorLogger == nil {
+		dInfo, dErr := defaultLoggers()
+		if infoLogger == nil {
+			infoLogger = dInfo
+		}
+		if errorLogger == nil {
+			errorLogger = dErr
+		}
+	}
+	return &ExportRepo{db: db, infoLogger: infoLogger, errorLogger: errorLogger}
+}
+
+const exportColumns = `CONVERT(nvarchar(36), id), CONVERT(nvarchar(36), user_id), status, storage_key, size_bytes,
+               error_message, created_at, completed_at, expires_at`
+
+func scanExport(row interface{ Scan(...interface{}) error }) (*models.DataExport, error) {
+	var e models.DataExport
+	var key, msg sql.NullString
+	var size sql.NullInt64
+	var completed, expires sql.NullTime
+	if err := row.Scan(&e.ID, &e.UserID, &e.Status, &key, &size, &msg, &e.CreatedAt, &completed, &expires); err != nil {
+		return nil, err
+	}
+	if key.Valid {
+		e.StorageKey = &key.String
+	}
+	if size.Valid {
+		e.SizeBytes = &size.Int64
+	}
+	if msg.Valid {
+		e.Error = &msg.String
+	}
+	if completed.Valid {
+		t := completed.Time
+		e.CompletedAt = &t
+	}
+	if expires.Valid {
+		t := expires.Time
+		e.ExpiresAt = &t
+	}
+	return &e, nil
+}
+
+// Create inserts a pending export for userID and enqueues its job in the same transaction.
+func (r *ExportRepo) Create(ctx context.Context, userID string) (*models.DataExport, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("ExportRepo.Create: begin tx failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	e := &models.DataExport{ID: uuid.New().String(), UserID: userID, Status: models.ExportPending, CreatedAt: time.Now().UTC()}
+	payload, _ := json.Marshal(ExportJobPayload{ExportID: e.ID})
+	jobID, err := insertJob(ctx, tx, JobTopicUserExport, string(payload), e.CreatedAt)
+	if err != nil {
+		r.errorLogger.Printf("ExportRepo.Create: enqueue failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	if _, err := tx.ExecContext(ctx, `
+        INSERT INTO dbo.data_exports (id, user_id, status, job_id, created_at, is_deleted)
+        VALUES (@p1, @p2, @p3, @p4, @p5, 0)
+    `, e.ID, userID, e.Status, jobID, e.CreatedAt); err != nil {
+		r.errorLogger.Printf("ExportRepo.Create: insert failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("ExportRepo.Create: commit failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	r.infoLogger.Printf("ExportRepo.Create: id=%s userID=%s job=%d", e.ID, userID, jobID)
+	return e, nil
+}
+
+// GetByID returns an export regardless of owner (worker use). nil, nil if not found.
+func (r *ExportRepo) GetByID(ctx context.Context, id string) (*models.DataExport, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		return nil, nil
+	}
+	e, err := scanExport(r.db.QueryRowContext(ctx, `
+        SELECT `+exportColumns+`
+        FROM dbo.data_exports WHERE id = @p1 AND is_deleted = 0
+    `, id))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.errorLogger.Printf("ExportRepo.GetByID: scan failed id=%s err=%v", id, err)
+		return nil, err
+	}
+	return e, nil
+}
+
+// GetForUser returns export id if it belongs to userID. nil, nil if not found.
+func (r *ExportRepo) GetForUser(ctx context.Context, userID, id string) (*models.DataExport, error) {
+	e, err := r.GetByID(ctx, id)
+	if err != nil || e == nil || e.UserID != userID {
+		return nil, err
+	}
+	return e, nil
+}
+
+// FindActive returns the user's pending or running export, if any.
+func (r *ExportRepo) FindActive(ctx context.Context, userID string) (*models.DataExport, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	e, err := scanExport(r.db.QueryRowContext(ctx, `
+        SELECT TOP 1 `+exportColumns+`
+        FROM dbo.data_exports
+        WHERE user_id = @p1 AND status IN ('pending','running') AND is_deleted = 0
+        ORDER BY created_at DESC
+    `, userID))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.errorLogger.Printf("ExportRepo.FindActive: scan failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	return e, nil
+}
+
+// MarkRunning flags an export as being built.
+func (r *ExportRepo) MarkRunning(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `UPDATE dbo.data_exports SET status = 'running' WHERE id = @p1`, id)
+	if err != nil {
+		r.errorLogger.Printf("ExportRepo.MarkRunning: update failed id=%s err=%v", id, err)
+	}
+	return err
+}
+
+// MarkCompleted records the stored file of a finished export.
+func (r *ExportRepo) MarkCompleted(ctx context.Context, id, storageKey string, size int64, expiresAt time.Time) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.data_exports
+        SET status = 'completed', storage_key = @p2, size_bytes = @p3, completed_at = SYSDATETIMEOFFSET(),
+            expires_at = @p4, error_message = NULL
+        WHERE id = @p1
+    `, id, storageKey, size, expiresAt)
+	if err != nil {
+		r.errorLogger.Printf("ExportRepo.MarkCompleted: update failed id=%s err=%v", id, err)
+	}
+	return err
+}
+
+// MarkFailed records a permanent failure.
+func (r *ExportRepo) MarkFailed(ctx context.Context, id, msg string) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.data_exports SET status = 'failed', error_message = @p2, completed_at = SYSDATETIMEOFFSET()
+        WHERE id = @p1
+    `, id, msg)
+	if err != nil {
+		r.errorLogger.Printf("ExportRepo.MarkFailed: update failed id=%s err=%v", id, err)
+	}
+	return err
+}
+
+// ListExpired returns up to limit completed exports whose download window has closed.
+func (r *ExportRepo) ListExpired(ctx context.Context, now time.Time, limit int) ([]models.DataExport, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT TOP (@p2) `+exportColumns+`
+        FROM dbo.data_exports
+        WHERE expires_at IS NOT NULL AND expires_at <= @p1 AND is_deleted = 0
+        ORDER BY expires_at
+    `, now, limit)
+	if err != nil {
+		r.errorLogger.Printf("ExportRepo.ListExpired: query failed err=%v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.DataExport
+	for rows.Next() {
+		e, err := scanExport(rows)
+		if err != nil {
+			r.errorLogger.Printf("ExportRepo.ListExpired: scan failed err=%v", err)
+			return nil, err
+		}
+		out = append(out, *e)
+	}
+	return out, rows.Err()
+}
+
+// MarkDeleted soft-deletes an export whose file was removed.
+func (r *ExportRepo) MarkDeleted(ctx context.Context, id string) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.data_exports SET is_deleted = 1, deleted_at = SYSDATETIMEOFFSET() WHERE id = @p1
+    `, id)
+	if err != nil {
+		r.errorLogger.Printf("ExportRepo.MarkDeleted: update failed id=%s err=%v", id, err)
+	}
+	return err
+}
+
+// ExportDatasets lists, in order, the files of an export and the query producing each.
+// Every query takes the user id as @p1; ids are converted to strings and GEOGRAPHY columns
+// are left out (latitude/longitude carry the same information).
+var ExportDatasets = []struct{ Name, Query string }{
+	{"profile", `
+        SELECT CONVERT(nvarchar(36), id) AS id, mobile_number, country_code, display_name, avatar_url, bio, email,
+               username, latitude, longitude, location_updated_at, date_of_birth, gender, is_mobile_verified,
+               is_email_verified, created_at, updated_at
+        FROM dbo.users WHERE id = @p1`},
+	{"preferences", `
+        SELECT notify_on_message, notify_on_like, notify_on_comment, timezone, lang, theme, created_at
+        FROM dbo.user_preferences WHERE user_id = @p1 AND is_deleted = 0`},
+	{"skills", `
+        SELECT s.game_type_id, g.name AS game_type, s.skill_level, s.experience_years, s.is_public, s.created_at
+        FROM dbo.user_skills s JOIN dbo.game_types g ON g.id = s.game_type_id
+        WHERE s.user_id = @p1 AND s.is_deleted = 0`},
+	{"posts", `
+        SELECT CONVERT(nvarchar(36), id) AS id, title, body, kind, latitude, longitude, category_id, visibility_id,
+               created_at, updated_at
+        FROM dbo.posts WHERE author_id = @p1 AND is_deleted = 0 ORDER BY created_at`},
+	{"comments", `
+        SELECT id, CONVERT(nvarchar(36), post_id) AS post_id, parent_comment_id, body, created_at, updated_at
+        FROM dbo.comments WHERE author_id = @p1 AND is_deleted = 0 ORDER BY created_at`},
+	{"messages", `
+        SELECT CONVERT(nvarchar(36), id) AS id, CONVERT(nvarchar(36), chat_id) AS chat_id, body, kind, created_at, edited_at
+        FROM dbo.messages WHERE sender_id = @p1 AND is_deleted = 0 ORDER BY created_at`},
+	{"tournaments", `
+        SELECT CONVERT(nvarchar(36), t.id) AS tournament_id, t.title, t.start_time, t.end_time, t.status AS tournament_status,
+               p.status AS participation_status, p.team_name, p.player_role, p.joined_at, p.created_at
+        FROM dbo.tournament_participants p JOIN dbo.tournaments t ON t.id = p.tournament_id
+        WHERE p.user_id = @p1 AND p.is_deleted = 0 ORDER BY p.created_at`},
+	{"notifications", `
+        SELECT id, kind, reference_type, reference_id, title, body, is_read, created_at, read_at
+        FROM dbo.notifications WHERE user_id = @p1 AND is_deleted = 0 ORDER BY created_at`},
+	{"sessions", `
+        SELECT CONVERT(nvarchar(36), id) AS id, device_info, ip_address, session_latitude AS latitude,
+               session_longitude AS longitude, created_at, expires_at, last_activity_at, is_revoked
+        FROM dbo.user_sessions WHERE user_id = @p1 AND is_deleted = 0 ORDER BY created_at`},
+}
+
+// EachDatasetRow runs one ExportDatasets query and calls fn with every row as a column->value
+// map, so a dataset never has to fit in memory. An error from fn stops the iteration.
+func (r *ExportRepo) EachDatasetRow(ctx context.Context, query, userID string, fn func(map[string]interface{}) error) error {
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		r.errorLogger.Printf("ExportRepo.EachDatasetRow: query failed userID=%s err=%v", userID, err)
+		return err
+	}
+	defer rows.Close()
+
+	cols, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		vals := make([]interface{}, len(cols))
+		ptrs := make([]interface{}, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			r.errorLogger.Printf("ExportRepo.EachDatasetRow: scan failed userID=%s err=%v", userID, err)
+			return err
+		}
+		m := make(map[string]interface{}, len(cols))
+		for i, c := range cols {
+			// DECIMAL columns come back as []byte text
+			if b, ok := vals[i].([]byte); ok {
+				m[c] = string(b)
+			} else {
+				m[c] = vals[i]
+			}
+		}
+		if err := fn(m); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
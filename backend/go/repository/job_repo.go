@@ -0,0 +1,148 @@
+/* Place: backend/go/repository/job_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"time"
+
+	"gatherup/models"
+)
+
+// JobRepo is a small queue on top of dbo.jobs. Workers claim jobs with a lease; a job whose
+// lease runs out (crashed worker) becomes claimable again.
+type JobRepo struct {
+	db          *sql.DB
+	infoLogger  *log.Logger
+	errorLogger *log.Logger
+}
+
+// NewJobRepo constructs a JobRepo. nil loggers fall back to the same defaults as NewUserRepo.
+func NewJobRepo(db *sql.DB, infoLogger, errorLogger *log.Logger) *JobRepo {
+	if infoLogger == nil || errorLogger == nil {
+		dInfo, dErr := defaultLoggers()
+		if infoLogger == nil {
+			infoLogger = dInfo
+		}
+		if errorLogger == nil {
+			errorLogger = dErr
+		}
+	}
+	return &JobRepo{db: db, infoLogger: infoLogger, errorLogger: errorLogger}
+}
+
+// execQuerier is satisfied by *sql.DB and *sql.Tx.
+type execQuerier interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// insertJob enqueues a pending job and returns its id; used inside other repos' transactions.
+func insertJob(ctx context.Context, q execQuerier, topic, payload string, runAt time.Time) (int64, error) {
+	var id int64
+	err := q.QueryRowContext(ctx, `
+        INSERT INTO dbo.jobs (topic, payload, attempts, max_attempts, run_at, status, created_at, is_deleted)
+        OUTPUT inserted.id
+        VALUES (@p1, @p2, 0, 3, @p3, 'pending', SYSDATETIMEOFFSET(), 0)
+    `, topic, payload, runAt).Scan(&id)
+	return id, err
+}
+
+// Enqueue adds a job for topic that becomes runnable at runAt.
+func (r *JobRepo) Enqueue(ctx context.Context, topic, payload string, runAt time.Time) (int64, error) {
+	id, err := insertJob(ctx, r.db, topic, payload, runAt)
+	if err != nil {
+		r.errorLogger.Printf("JobRepo.Enqueue: insert failed topic=%s err=%v", topic, err)
+		return 0, err
+	}
+	return id, nil
+}
+
+// Claim locks the next runnable job of topic for workerID until now+lease and counts the
+// attempt. A job whose lease ran out is only reclaimed while it has attempts left; see
+// FailExhausted for the rest. Returns nil, nil when there is nothing to do.
+func (r *JobRepo) Claim(ctx context.Context, topic, workerID string, lease time.Duration) (*models.Job, error) {
+	now := time.Now().UTC()
+	var j models.Job
+	err := r.db.QueryRowContext(ctx, `
+        WITH next AS (
+            SELECT TOP (1) * FROM dbo.jobs WITH (ROWLOCK, READPAST, UPDLOCK)
+            WHERE topic = @p1 AND is_deleted = 0 AND run_at <= @p2
+              AND (status = 'pending' OR (status = 'running' AND locked_until < @p2 AND attempts < max_attempts))
+            ORDER BY run_at
+        )
+        UPDATE next SET status = 'running', attempts = attempts + 1, locked_until = @p3, locked_by = @p4
+        OUTPUT inserted.id, inserted.topic, inserted.payload, inserted.attempts, inserted.max_attempts
+    `, topic, now, now.Add(lease), workerID).Scan(&j.ID, &j.Topic, &j.Payload, &j.Attempts, &j.MaxAttempts)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.errorLogger.Printf("JobRepo.Claim: failed topic=%s err=%v", topic, err)
+		return nil, err
+	}
+	return &j, nil
+}
+
+// FailExhausted marks failed the jobs of topic whose lease ran out on their last attempt (the
+// worker crashed or was killed while running them) and returns them, so the caller can give up
+// on whatever they were doing.
+func (r *JobRepo) FailExhausted(ctx context.Context, topic string) ([]models.Job, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        UPDATE dbo.jobs SET status = 'failed', locked_until = NULL,
+               error_message = 'lease expired on the last attempt'
+        OUTPUT inserted.id, inserted.topic, inserted.payload, inserted.attempts, inserted.max_attempts
+        WHERE topic = @p1 AND is_deleted = 0 AND status = 'running' AND locked_until < @p2
+          AND attempts >= max_attempts
+    `, topic, time.Now().UTC())
+	if err != nil {
+		r.errorLogger.Printf("JobRepo.FailExhausted: update failed topic=%s err=%v", topic, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.Job
+	for rows.Next() {
+		var j models.Job
+		if err := rows.Scan(&j.ID, &j.Topic, &j.Payload, &j.Attempts, &j.MaxAttempts); err != nil {
+			r.errorLogger.Printf("JobRepo.FailExhausted: scan failed topic=%s err=%v", topic, err)
+			return nil, err
+		}
+		r.infoLogger.Printf("JobRepo.FailExhausted: id=%d topic=%s attempts=%d", j.ID, j.Topic, j.Attempts)
+		out = append(out, j)
+	}
+	return out, rows.Err()
+}
+
+// Complete marks a job done.
+func (r *JobRepo) Complete(ctx context.Context, id int64) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.jobs SET status = 'completed', completed_at = SYSDATETIMEOFFSET(), locked_until = NULL, error_message = NULL
+        WHERE id = @p1
+    `, id)
+	if err != nil {
+		r.errorLogger.Printf("JobRepo.Complete: update failed id=%d err=%v", id, err)
+	}
+	return err
+}
+
+// Fail records an error. The job is retried at retryAt unless it used all attempts, in which
+// case it is marked failed; the return value reports whether it will be retried.
+func (r *JobRepo) Fail(ctx context.Context, j *models.Job, msg string, retryAt time.Time) (bool, error) {
+	retry := j.Attempts < j.MaxAttempts
+	status := "failed"
+	if retry {
+		status = "pending"
+	}
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.jobs SET status = @p2, error_message = @p3, run_at = @p4, locked_until = NULL
+        WHERE id = @p1
+    `, j.ID, status, msg, retryAt)
+	if err != nil {
+		r.errorLogger.Printf("JobRepo.Fail: update failed id=%d err=%v", j.ID, err)
+		return false, err
+	}
+	r.infoLogger.Printf("JobRepo.Fail: id=%d topic=%s attempt=%d/%d retry=%v err=%s", j.ID, j.Topic, j.Attempts, j.MaxAttempts, retry, msg)
+	return retry, nil
+}
@@ -0,0 +1,124 @@
+/* Place: backend/go/repository/location_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"math"
+	"time"
+
+	"gatherup/geo"
+	"gatherup/models"
+
+	"github.com/google/uuid"
+)
+
+// UpdateLocation stores p as the user's location (latitude, longitude, GEOGRAPHY point and
+// location_updated_at), or clears all of them when p is nil. expectedRV works as in UpdateProfile.
+func (r *UserRepo) UpdateLocation(ctx context.Context, userID string, p *geo.Point, expectedRV []byte) (bool, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	var lat, lon *float64
+	if p != nil {
+		lat, lon = &p.Lat, &p.Lon
+	}
+	args := []interface{}{userID, sqlNullFloat(lat), sqlNullFloat(lon)}
+	where := "id = @p1 AND is_deleted = 0"
+	if expectedRV != nil {
+		args = append(args, expectedRV)
+		where += " AND rv = @p4"
+	}
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.users
+        SET latitude = @p2, longitude = @p3,
+            location = CASE WHEN @p2 IS NULL OR @p3 IS NULL THEN NULL ELSE geography::Point(@p2, @p3, 4326) END,
+            location_updated_at = CASE WHEN @p2 IS NULL THEN NULL ELSE SYSDATETIMEOFFSET() END,
+            updated_at = SYSDATETIMEOFFSET()
+        WHERE `+where, args...)
+	if err != nil {
+		r.errorLogger.Printf("UpdateLocation: update failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	if n == 0 && expectedRV != nil {
+		u, err := r.GetByID(ctx, userID)
+		if err != nil {
+			return false, err
+		}
+		if u != nil {
+			return false, ErrStale
+		}
+	}
+	r.infoLogger.Printf("UpdateLocation: userID=%s cleared=%v updated=%d", userID, p == nil, n)
+	return n > 0, nil
+}
+
+// NearbyCandidate is a user inside the search box, before exact distance filtering.
+type NearbyCandidate struct {
+	models.NearbyUser
+	Point geo.Point
+}
+
+// ListUsersInBox returns up to limit active, non-bot users whose location lies in box and was
+// updated after since, excluding viewerID and users blocked in either direction. With
+// gameTypeCode only users with a public skill for that game type are returned. The limit keeps
+// the users closest to center, by an equirectangular approximation that is exact enough to rank
+// within one box.
+func (r *UserRepo) ListUsersInBox(ctx context.Context, viewerID string, center geo.Point, box geo.Box, gameTypeCode string, since time.Time, limit int) ([]NearbyCandidate, error) {
+	if _, err := uuid.Parse(viewerID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT TOP (@p8) CONVERT(nvarchar(36), u.id), u.username, u.display_name, u.avatar_url, u.latitude, u.longitude,
+               CASE WHEN `+isContactSQL("@p1", "u.id")+` THEN 1 ELSE 0 END,
+               s.skill_level
+        FROM dbo.users u
+        LEFT JOIN dbo.user_skills s ON @p6 <> '' AND s.user_id = u.id AND s.is_deleted = 0 AND ISNULL(s.is_public, 1) = 1
+             AND s.game_type_id = (SELECT id FROM dbo.game_types WHERE code = @p6 AND is_deleted = 0)
+        WHERE u.is_deleted = 0 AND u.is_active = 1 AND u.is_bot = 0 AND u.id <> @p1
+          AND u.latitude IS NOT NULL AND u.longitude IS NOT NULL AND u.location_updated_at >= @p7
+          AND u.latitude BETWEEN @p2 AND @p3
+          AND ((@p4 <= @p5 AND u.longitude BETWEEN @p4 AND @p5) OR (@p4 > @p5 AND (u.longitude >= @p4 OR u.longitude <= @p5)))
+          AND (@p6 = '' OR s.id IS NOT NULL)
+          AND NOT `+blockedSQL("@p1", "u.id")+`
+        ORDER BY SQUARE(u.latitude - @p9)
+               + SQUARE(@p11 * CASE WHEN ABS(u.longitude - @p10) > 180 THEN 360 - ABS(u.longitude - @p10)
+                                    ELSE ABS(u.longitude - @p10) END),
+                 u.location_updated_at DESC
+    `, viewerID, box.MinLat, box.MaxLat, box.MinLon, box.MaxLon, gameTypeCode, since, limit,
+		center.Lat, center.Lon, math.Cos(center.Lat*math.Pi/180))
+	if err != nil {
+		r.errorLogger.Printf("ListUsersInBox: query failed viewer=%s err=%v", viewerID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []NearbyCandidate
+	for rows.Next() {
+		var c NearbyCandidate
+		var username, displayName, avatarURL, level sql.NullString
+		if err := rows.Scan(&c.ID, &username, &displayName, &avatarURL, &c.Point.Lat, &c.Point.Lon, &c.IsContact, &level); err != nil {
+			r.errorLogger.Printf("ListUsersInBox: scan failed viewer=%s err=%v", viewerID, err)
+			return nil, err
+		}
+		c.Username = nullStringPtr(username)
+		c.DisplayName = nullStringPtr(displayName)
+		c.AvatarURL = nullStringPtr(avatarURL)
+		c.SkillLevel = nullStringPtr(level)
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+// GameTypeExists reports whether code names a game type.
+func (r *UserRepo) GameTypeExists(ctx context.Context, code string) (bool, error) {
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM dbo.game_types WHERE code = @p1 AND is_deleted = 0`, code).Scan(&n)
+	if err != nil {
+		r.errorLogger.Printf("GameTypeExists: query failed code=%s err=%v", code, err)
+		return false, err
+	}
+	return n > 0, nil
+}
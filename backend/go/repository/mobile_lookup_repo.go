@@ -0,0 +1,104 @@
+/* Place: backend/go/repository/mobile_lookup_repo.go */
+package repository
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// MobileLookupPending is a verified user whose mobile_lookup_hash still has to be computed.
+type MobileLookupPending struct {
+	UserID string
+	Mobile string
+}
+
+// MobileLookupMatch is a user whose mobile_lookup_hash equals Hash.
+type MobileLookupMatch struct {
+	Hash   []byte
+	UserID string
+}
+
+// ListMobileLookupPending returns up to limit verified users without a mobile_lookup_hash.
+func (r *UserRepo) ListMobileLookupPending(ctx context.Context, limit int) ([]MobileLookupPending, error) {
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT TOP (@p1) CONVERT(nvarchar(36), id), mobile_normalized
+        FROM dbo.users
+        WHERE mobile_lookup_hash IS NULL AND is_mobile_verified = 1 AND is_deleted = 0
+    `, limit)
+	if err != nil {
+		r.errorLogger.Printf("ListMobileLookupPending: query failed err=%v", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MobileLookupPending
+	for rows.Next() {
+		var p MobileLookupPending
+		if err := rows.Scan(&p.UserID, &p.Mobile); err != nil {
+			r.errorLogger.Printf("ListMobileLookupPending: scan failed err=%v", err)
+			return nil, err
+		}
+		out = append(out, p)
+	}
+	return out, rows.Err()
+}
+
+// SetMobileLookupHash stores hash for userID, provided the number it was computed from is
+// still the user's verified mobile.
+func (r *UserRepo) SetMobileLookupHash(ctx context.Context, userID, mobile string, hash []byte) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.users SET mobile_lookup_hash = @p3
+        WHERE id = @p1 AND mobile_normalized = @p2 AND is_mobile_verified = 1 AND is_deleted = 0
+    `, userID, mobile, hash)
+	if err != nil {
+		r.errorLogger.Printf("SetMobileLookupHash: exec failed userID=%s err=%v", userID, err)
+	}
+	return err
+}
+
+// MatchMobileLookupHashes returns the active, non-bot users other than viewerID whose verified
+// mobile hashes to one of hashes, excluding users blocked in either direction.
+func (r *UserRepo) MatchMobileLookupHashes(ctx context.Context, viewerID string, hashes [][]byte) ([]MobileLookupMatch, error) {
+	if _, err := uuid.Parse(viewerID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	if len(hashes) == 0 {
+		return nil, nil
+	}
+	args := make([]interface{}, 0, len(hashes)+1)
+	args = append(args, viewerID)
+	params := make([]string, 0, len(hashes))
+	for i, h := range hashes {
+		args = append(args, h)
+		params = append(params, fmt.Sprintf("@p%d", i+2))
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT u.mobile_lookup_hash, CONVERT(nvarchar(36), u.id)
+        FROM dbo.users u
+        WHERE u.mobile_lookup_hash IN (`+strings.Join(params, ",")+`)
+          AND u.is_mobile_verified = 1 AND u.is_deleted = 0 AND u.is_active = 1 AND u.is_bot = 0
+          AND u.id <> @p1 AND NOT `+blockedSQL("@p1", "u.id")+`
+    `, args...)
+	if err != nil {
+		r.errorLogger.Printf("MatchMobileLookupHashes: query failed viewer=%s err=%v", viewerID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []MobileLookupMatch
+	for rows.Next() {
+		var m MobileLookupMatch
+		if err := rows.Scan(&m.Hash, &m.UserID); err != nil {
+			r.errorLogger.Printf("MatchMobileLookupHashes: scan failed viewer=%s err=%v", viewerID, err)
+			return nil, err
+		}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,29 @@
+/* Place: backend/go/repository/notification_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"gatherup/models"
+)
+
+// execer is satisfied by *sql.DB and *sql.Tx.
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+}
+
+// insertNotification adds an in-app notification to dbo.notifications; used inside other
+// repos' transactions so the notification is written together with the change it announces.
+func insertNotification(ctx context.Context, ex execer, n *models.Notification) error {
+	if n.CreatedAt.IsZero() {
+		n.CreatedAt = time.Now().UTC()
+	}
+	_, err := ex.ExecContext(ctx, `
+        INSERT INTO dbo.notifications (user_id, actor_id, kind, reference_type, reference_id, title, body, is_read, created_at, is_deleted)
+        VALUES (@p1, @p2, @p3, @p4, @p5, @p6, @p7, 0, @p8, 0)
+    `, n.UserID, sqlNullString(n.ActorID), n.Kind, sqlNullString(n.ReferenceType), sqlNullString(n.ReferenceID),
+		sqlNullString(n.Title), sqlNullString(n.Body), n.CreatedAt)
+	return err
+}
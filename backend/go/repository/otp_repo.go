@@ -0,0 +1,148 @@
+/* Place: backend/go/repository/otp_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gatherup/models"
+
+	"github.com/google/uuid"
+)
+
+// OTPRepo stores hashed one-time codes in dbo.otp_codes.
+type OTPRepo struct {
+	db          *sql.DB
+	infoLogger  *log.Logger
+	errorLogger *log.Logger
+}
+
+// NewOTPRepo constructs an OTPRepo. nil loggers fall back to the same defaults as NewUserRepo.
+func NewOTPRepo(db *sql.DB, infoLogger, errorLogger *log.Logger) *OTPRepo {
+	if infoLogger == nil || errorLogger == nil {
+		dInfo, dErr := defaultLoggers()
+		if infoLogger == nil {
+			infoLogger = dInfo
+		}
+		if errorLogger == nil {
+			errorLogger = dErr
+		}
+	}
+	return &OTPRepo{db: db, infoLogger: infoLogger, errorLogger: errorLogger}
+}
+
+// Create invalidates any outstanding code of the same user for the same destination/purpose and
+// inserts a new one. Codes another user requested for that destination stay valid.
+func (r *OTPRepo) Create(ctx context.Context, c *models.OTPCode) (string, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("OTPRepo.Create: begin tx failed err=%v", err)
+		return "", err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	if _, err = tx.ExecContext(ctx, `
+        UPDATE dbo.otp_codes SET is_deleted = 1, deleted_at = SYSDATETIMEOFFSET()
+        WHERE destination = @p1 AND purpose = @p2 AND consumed_at IS NULL AND is_deleted = 0
+          AND (user_id = @p3 OR (user_id IS NULL AND @p3 IS NULL))
+    `, c.Destination, c.Purpose, sqlNullString(c.UserID)); err != nil {
+		r.errorLogger.Printf("OTPRepo.Create: invalidate previous failed purpose=%s err=%v", c.Purpose, err)
+		return "", err
+	}
+
+	id := uuid.New().String()
+	now := time.Now().UTC()
+	if _, err = tx.ExecContext(ctx, `
+        INSERT INTO dbo.otp_codes (id, user_id, purpose, channel, destination, code_hash, attempts, max_attempts, expires_at, created_at, is_deleted)
+        VALUES (@p1, @p2, @p3, @p4, @p5, @p6, 0, @p7, @p8, @p9, 0)
+    `, id, sqlNullString(c.UserID), c.Purpose, c.Channel, c.Destination, c.CodeHash, c.MaxAttempts, c.ExpiresAt, now); err != nil {
+		r.errorLogger.Printf("OTPRepo.Create: insert failed id=%s purpose=%s err=%v", id, c.Purpose, err)
+		return "", fmt.Errorf("create otp failed: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("OTPRepo.Create: commit failed id=%s err=%v", id, err)
+		return "", err
+	}
+	c.ID = id
+	c.CreatedAt = now
+	r.infoLogger.Printf("OTPRepo.Create: created id=%s purpose=%s channel=%s", id, c.Purpose, c.Channel)
+	return id, nil
+}
+
+// GetLatestActive returns the newest unconsumed code for destination/purpose (expired codes included,
+// so callers can report expiry), only among codes issued to userID when it is non-nil.
+// Returns nil, nil if none.
+func (r *OTPRepo) GetLatestActive(ctx context.Context, destination, purpose string, userID *string) (*models.OTPCode, error) {
+	row := r.db.QueryRowContext(ctx, `
+        SELECT TOP 1 CONVERT(nvarchar(36), id), CONVERT(nvarchar(36), user_id), purpose, channel, destination,
+               code_hash, attempts, max_attempts, expires_at, created_at
+        FROM dbo.otp_codes
+        WHERE destination = @p1 AND purpose = @p2 AND consumed_at IS NULL AND is_deleted = 0
+          AND (@p3 IS NULL OR user_id = @p3)
+        ORDER BY created_at DESC
+    `, destination, purpose, sqlNullString(userID))
+
+	c := &models.OTPCode{}
+	var uid sql.NullString
+	if err := row.Scan(&c.ID, &uid, &c.Purpose, &c.Channel, &c.Destination, &c.CodeHash, &c.Attempts, &c.MaxAttempts, &c.ExpiresAt, &c.CreatedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.errorLogger.Printf("OTPRepo.GetLatestActive: scan failed purpose=%s err=%v", purpose, err)
+		return nil, err
+	}
+	if uid.Valid {
+		c.UserID = &uid.String
+	}
+	return c, nil
+}
+
+// CountSince returns how many codes were issued for destination/purpose since t (rate limiting).
+func (r *OTPRepo) CountSince(ctx context.Context, destination, purpose string, since time.Time) (int, error) {
+	var n int
+	row := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(1) FROM dbo.otp_codes
+        WHERE destination = @p1 AND purpose = @p2 AND created_at >= @p3
+    `, destination, purpose, since)
+	if err := row.Scan(&n); err != nil {
+		r.errorLogger.Printf("OTPRepo.CountSince: scan failed purpose=%s err=%v", purpose, err)
+		return 0, err
+	}
+	return n, nil
+}
+
+// ClaimAttempt counts a verification attempt against the code. The check and the increment
+// are one statement, so concurrent guesses can't exceed max_attempts. Returns false when the
+// code is out of attempts or already consumed.
+func (r *OTPRepo) ClaimAttempt(ctx context.Context, id string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.otp_codes SET attempts = attempts + 1
+        WHERE id = @p1 AND attempts < max_attempts AND consumed_at IS NULL AND is_deleted = 0
+    `, id)
+	if err != nil {
+		r.errorLogger.Printf("OTPRepo.ClaimAttempt: exec failed id=%s err=%v", id, err)
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
+
+// Consume marks a code used. Returns false if it was consumed concurrently.
+func (r *OTPRepo) Consume(ctx context.Context, id string) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.otp_codes SET consumed_at = SYSDATETIMEOFFSET()
+        WHERE id = @p1 AND consumed_at IS NULL AND is_deleted = 0
+    `, id)
+	if err != nil {
+		r.errorLogger.Printf("OTPRepo.Consume: exec failed id=%s err=%v", id, err)
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n == 1, nil
+}
@@ -0,0 +1,121 @@
+/* Place: backend/go/repository/preferences_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"gatherup/models"
+
+	"github.com/google/uuid"
+)
+
+// preferenceColumns are the dbo.user_preferences columns SavePreferences may write.
+var preferenceColumns = map[string]bool{
+	"notify_on_message": true,
+	"notify_on_like":    true,
+	"notify_on_comment": true,
+	"timezone":          true,
+	"lang":              true,
+	"theme":             true,
+}
+
+// GetPreferences returns the user's preferences with defaults for NULL columns, or nil, nil
+// if the row was never created.
+func (r *UserRepo) GetPreferences(ctx context.Context, userID string) (*models.UserPreferences, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	var onMessage, onLike, onComment sql.NullBool
+	var timezone, lang, theme sql.NullString
+	err := r.db.QueryRowContext(ctx, `
+        SELECT notify_on_message, notify_on_like, notify_on_comment, timezone, lang, theme
+        FROM dbo.user_preferences
+        WHERE user_id = @p1 AND is_deleted = 0
+    `, userID).Scan(&onMessage, &onLike, &onComment, &timezone, &lang, &theme)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.errorLogger.Printf("GetPreferences: scan failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	p := models.DefaultPreferences()
+	if onMessage.Valid {
+		p.NotifyOnMessage = onMessage.Bool
+	}
+	if onLike.Valid {
+		p.NotifyOnLike = onLike.Bool
+	}
+	if onComment.Valid {
+		p.NotifyOnComment = onComment.Bool
+	}
+	if timezone.Valid && timezone.String != "" {
+		p.Timezone = timezone.String
+	}
+	if lang.Valid && lang.String != "" {
+		p.Lang = lang.String
+	}
+	if theme.Valid && theme.String != "" {
+		p.Theme = theme.String
+	}
+	return &p, nil
+}
+
+// SavePreferences sets the given columns (nil values reset them to the default), creating
+// the user's row first if it does not exist yet.
+func (r *UserRepo) SavePreferences(ctx context.Context, userID string, changes map[string]interface{}) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+	cols := make([]string, 0, len(changes))
+	for c := range changes {
+		if !preferenceColumns[c] {
+			return fmt.Errorf("column %q is not a preference column", c)
+		}
+		cols = append(cols, c)
+	}
+	sort.Strings(cols)
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("SavePreferences: begin tx failed userID=%s err=%v", userID, err)
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	// the range lock keeps two first saves from both inserting
+	if _, err := tx.ExecContext(ctx, `
+        IF NOT EXISTS (SELECT 1 FROM dbo.user_preferences WITH (UPDLOCK, HOLDLOCK) WHERE user_id = @p1)
+            INSERT INTO dbo.user_preferences (user_id) VALUES (@p1)
+    `, userID); err != nil {
+		r.errorLogger.Printf("SavePreferences: create row failed userID=%s err=%v", userID, err)
+		return err
+	}
+	if len(cols) > 0 {
+		args := []interface{}{userID}
+		sets := make([]string, 0, len(cols))
+		for _, c := range cols {
+			args = append(args, changes[c])
+			sets = append(sets, fmt.Sprintf("%s = @p%d", c, len(args)))
+		}
+		if _, err := tx.ExecContext(ctx, `
+            UPDATE dbo.user_preferences SET `+strings.Join(sets, ", ")+`
+            WHERE user_id = @p1 AND is_deleted = 0`, args...); err != nil {
+			r.errorLogger.Printf("SavePreferences: update failed userID=%s err=%v", userID, err)
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("SavePreferences: commit failed userID=%s err=%v", userID, err)
+		return err
+	}
+	r.infoLogger.Printf("SavePreferences: userID=%s columns=%v", userID, cols)
+	return nil
+}
@@ -0,0 +1,100 @@
+/* Place: backend/go/repository/profile_repo.go */
+package repository
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// profileColumns are the dbo.users columns UpdateProfile may write.
+var profileColumns = map[string]bool{
+	"display_name":  true,
+	"username":      true,
+	"avatar_url":    true,
+	"bio":           true,
+	"gender":        true,
+	"date_of_birth": true,
+}
+
+// UpdateProfile sets the given profile columns (nil values clear them) and updated_at. When
+// username actually changes, username_changed_at is set too. With expectedRV the update only
+// applies to that rowversion and returns ErrStale otherwise. A non-zero usernameCutoff makes a
+// username change apply only if the previous one was at or before it (ErrUsernameCooldown
+// otherwise); the check is part of the UPDATE so concurrent renames cannot all pass it.
+// Returns false if the user does not exist, ErrDuplicate if the username is taken (ux_users_username).
+func (r *UserRepo) UpdateProfile(ctx context.Context, userID string, changes map[string]interface{}, expectedRV []byte, usernameCutoff time.Time) (bool, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	cols := make([]string, 0, len(changes))
+	for c := range changes {
+		if !profileColumns[c] {
+			return false, fmt.Errorf("column %q is not a profile column", c)
+		}
+		cols = append(cols, c)
+	}
+	sort.Strings(cols)
+
+	args := []interface{}{userID}
+	sets := make([]string, 0, len(cols)+2)
+	for _, c := range cols {
+		args = append(args, changes[c])
+		p := fmt.Sprintf("@p%d", len(args))
+		if c == "username" {
+			// compared under the column collation, so a case-only change is not a change
+			sets = append(sets, fmt.Sprintf(
+				"username_changed_at = CASE WHEN username IS NULL OR %[1]s IS NULL OR username <> %[1]s THEN SYSDATETIMEOFFSET() ELSE username_changed_at END", p))
+		}
+		sets = append(sets, c+" = "+p)
+	}
+	sets = append(sets, "updated_at = SYSDATETIMEOFFSET()")
+	where := "id = @p1 AND is_deleted = 0"
+	if expectedRV != nil {
+		args = append(args, expectedRV)
+		where += fmt.Sprintf(" AND rv = @p%d", len(args))
+	}
+	name, renaming := changes["username"]
+	if renaming && !usernameCutoff.IsZero() {
+		args = append(args, usernameCutoff, name)
+		where += fmt.Sprintf(` AND (username IS NULL OR username_changed_at IS NULL OR username_changed_at <= @p%d
+            OR username = @p%d)`, len(args)-1, len(args))
+	}
+
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.users SET `+strings.Join(sets, ", ")+`
+        WHERE `+where, args...)
+	if err != nil {
+		if isUniqueViolation(err) {
+			return false, ErrDuplicate
+		}
+		r.errorLogger.Printf("UpdateProfile: update failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	r.infoLogger.Printf("UpdateProfile: userID=%s columns=%v updated=%d", userID, cols, n)
+	if n == 0 && (expectedRV != nil || renaming && !usernameCutoff.IsZero()) {
+		// find out which condition failed
+		var rv []byte
+		err := r.db.QueryRowContext(ctx, `SELECT rv FROM dbo.users WHERE id = @p1 AND is_deleted = 0`, userID).Scan(&rv)
+		if errors.Is(err, sql.ErrNoRows) {
+			return false, nil
+		}
+		if err != nil {
+			r.errorLogger.Printf("UpdateProfile: exists check failed userID=%s err=%v", userID, err)
+			return false, err
+		}
+		if expectedRV != nil && !bytes.Equal(rv, expectedRV) {
+			return false, ErrStale
+		}
+		return false, ErrUsernameCooldown
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,99 @@
+/* Place: backend/go/repository/public_profile_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gatherup/models"
+
+	"github.com/google/uuid"
+)
+
+// GetRelationship reports whether viewerID and targetID are accepted contacts and whether
+// either one blocked the other.
+func (r *UserRepo) GetRelationship(ctx context.Context, viewerID, targetID string) (contact, blocked bool, err error) {
+	if _, err := uuid.Parse(viewerID); err != nil {
+		return false, false, fmt.Errorf("invalid user id: %w", err)
+	}
+	if _, err := uuid.Parse(targetID); err != nil {
+		return false, false, fmt.Errorf("invalid user id: %w", err)
+	}
+	row := r.db.QueryRowContext(ctx, `
+        SELECT
+            CASE WHEN `+isContactSQL("@p1", "@p2")+` THEN 1 ELSE 0 END,
+            CASE WHEN `+blockedSQL("@p1", "@p2")+` THEN 1 ELSE 0 END
+    `, viewerID, targetID)
+	if err := row.Scan(&contact, &blocked); err != nil {
+		r.errorLogger.Printf("GetRelationship: scan failed viewer=%s target=%s err=%v", viewerID, targetID, err)
+		return false, false, err
+	}
+	return contact, blocked, nil
+}
+
+// ListSkills returns the user's skills; private ones only with includePrivate.
+func (r *UserRepo) ListSkills(ctx context.Context, userID string, includePrivate bool) ([]models.UserSkill, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT s.game_type_id, g.code, g.name, s.skill_level, s.experience_years, ISNULL(s.is_public, 1)
+        FROM dbo.user_skills s JOIN dbo.game_types g ON g.id = s.game_type_id
+        WHERE s.user_id = @p1 AND s.is_deleted = 0 AND (@p2 = 1 OR ISNULL(s.is_public, 1) = 1)
+        ORDER BY g.name
+    `, userID, includePrivate)
+	if err != nil {
+		r.errorLogger.Printf("ListSkills: query failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.UserSkill{}
+	for rows.Next() {
+		var s models.UserSkill
+		var level sql.NullString
+		var years sql.NullInt64
+		if err := rows.Scan(&s.GameTypeID, &s.GameTypeCode, &s.GameTypeName, &level, &years, &s.IsPublic); err != nil {
+			r.errorLogger.Printf("ListSkills: scan failed userID=%s err=%v", userID, err)
+			return nil, err
+		}
+		s.SkillLevel = nullStringPtr(level)
+		if years.Valid {
+			y := int(years.Int64)
+			s.ExperienceYears = &y
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
+
+// GetProfileStats counts the user's posts with one of visibilities and their tournaments.
+func (r *UserRepo) GetProfileStats(ctx context.Context, userID string, visibilities []int) (models.ProfileStats, error) {
+	var st models.ProfileStats
+	if _, err := uuid.Parse(userID); err != nil {
+		return st, fmt.Errorf("invalid user id: %w", err)
+	}
+	ids := make([]string, 0, len(visibilities))
+	for _, v := range visibilities {
+		ids = append(ids, strconv.Itoa(v))
+	}
+	postsFilter := "1 = 0"
+	if len(ids) > 0 {
+		postsFilter = "visibility_id IN (" + strings.Join(ids, ",") + ")"
+	}
+	row := r.db.QueryRowContext(ctx, `
+        SELECT
+            (SELECT COUNT(1) FROM dbo.posts WHERE author_id = @p1 AND is_deleted = 0 AND `+postsFilter+`),
+            (SELECT COUNT(1) FROM dbo.tournament_participants p JOIN dbo.tournaments t ON t.id = p.tournament_id
+              WHERE p.user_id = @p1 AND p.is_deleted = 0 AND p.joined_at IS NOT NULL AND t.is_deleted = 0),
+            (SELECT COUNT(1) FROM dbo.tournaments WHERE creator_id = @p1 AND is_deleted = 0)
+    `, userID)
+	if err := row.Scan(&st.Posts, &st.TournamentsPlayed, &st.TournamentsOrganized); err != nil {
+		r.errorLogger.Printf("GetProfileStats: scan failed userID=%s err=%v", userID, err)
+		return st, err
+	}
+	return st, nil
+}
@@ -0,0 +1,73 @@
+/* Place: backend/go/repository/role_repo.go */
+package repository
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// GetRoles returns the explicitly granted roles of a user (the implicit "user" role is not stored).
+func (r *UserRepo) GetRoles(ctx context.Context, userID string) ([]string, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT role FROM dbo.user_roles
+        WHERE user_id = @p1 AND is_deleted = 0
+        ORDER BY role
+    `, userID)
+	if err != nil {
+		r.errorLogger.Printf("GetRoles: query failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var roles []string
+	for rows.Next() {
+		var role string
+		if err := rows.Scan(&role); err != nil {
+			r.errorLogger.Printf("GetRoles: scan failed userID=%s err=%v", userID, err)
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+// GrantRole assigns role to user (idempotent). grantedBy may be nil for system grants.
+func (r *UserRepo) GrantRole(ctx context.Context, userID, role string, grantedBy *string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+	_, err := r.db.ExecContext(ctx, `
+        IF NOT EXISTS (SELECT 1 FROM dbo.user_roles WHERE user_id = @p1 AND role = @p2 AND is_deleted = 0)
+            INSERT INTO dbo.user_roles (user_id, role, granted_by, created_at, is_deleted)
+            VALUES (@p1, @p2, @p3, SYSDATETIMEOFFSET(), 0)
+    `, userID, role, sqlNullString(grantedBy))
+	if err != nil {
+		r.errorLogger.Printf("GrantRole: exec failed userID=%s role=%s err=%v", userID, role, err)
+		return err
+	}
+	r.infoLogger.Printf("GrantRole: userID=%s role=%s", userID, role)
+	return nil
+}
+
+// RevokeRole soft-deletes a role assignment. Returns false if the user did not have it.
+func (r *UserRepo) RevokeRole(ctx context.Context, userID, role string) (bool, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.user_roles SET is_deleted = 1, deleted_at = SYSDATETIMEOFFSET()
+        WHERE user_id = @p1 AND role = @p2 AND is_deleted = 0
+    `, userID, role)
+	if err != nil {
+		r.errorLogger.Printf("RevokeRole: exec failed userID=%s role=%s err=%v", userID, role, err)
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	r.infoLogger.Printf("RevokeRole: userID=%s role=%s revoked=%d", userID, role, n)
+	return n > 0, nil
+}
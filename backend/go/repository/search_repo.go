@@ -0,0 +1,111 @@
+/* Place: backend/go/repository/search_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"gatherup/models"
+
+	"github.com/google/uuid"
+)
+
+// UserSearchCursor is the sort key of the last result of a page; the next page starts after it.
+type UserSearchCursor struct {
+	ContactRank int    `json:"c"`
+	MatchRank   int    `json:"m"`
+	SortName    string `json:"n"`
+	ID          string `json:"i"`
+}
+
+// UserSearchParams selects users matching Prefix (username or display name) or exactly
+// Mobile (normalized, verified numbers only), as seen by ViewerID.
+type UserSearchParams struct {
+	ViewerID string
+	Prefix   string
+	Mobile   string
+	After    *UserSearchCursor
+	Limit    int
+}
+
+// UserSearchHit is one search result with its cursor.
+type UserSearchHit struct {
+	models.UserSummary
+	Cursor UserSearchCursor
+}
+
+// likePrefix escapes LIKE wildcards in s and appends %; use with ESCAPE '\'.
+func likePrefix(s string) string {
+	r := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `[`, `\[`)
+	return r.Replace(s) + "%"
+}
+
+// SearchUsers returns active users matching p, excluding the viewer and anyone either side
+// blocked. Results are ordered contacts first, then exact mobile, exact username, username
+// prefix and display name matches, then by name.
+func (r *UserRepo) SearchUsers(ctx context.Context, p UserSearchParams) ([]UserSearchHit, error) {
+	if _, err := uuid.Parse(p.ViewerID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	prefix := ""
+	if p.Prefix != "" {
+		prefix = likePrefix(p.Prefix)
+	}
+	after := UserSearchCursor{}
+	hasCursor := p.After != nil
+	if hasCursor {
+		after = *p.After
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+        WITH matches AS (
+            SELECT CONVERT(nvarchar(36), u.id) AS id, u.username, u.display_name, u.avatar_url, u.is_bot,
+                   CASE WHEN `+isContactSQL("@p1", "u.id")+` THEN 0 ELSE 1 END AS contact_rank,
+                   CASE WHEN @p3 <> '' AND u.mobile_normalized = @p3 THEN 0
+                        WHEN @p4 <> '' AND u.username = @p4 THEN 1
+                        WHEN @p2 <> '' AND u.username LIKE @p2 ESCAPE '\' THEN 2
+                        ELSE 3 END AS match_rank,
+                   COALESCE(u.username, u.display_name, N'') AS sort_name
+            FROM dbo.users u
+            WHERE u.is_deleted = 0 AND u.is_active = 1 AND u.id <> @p1
+              AND ((@p2 <> '' AND (u.username LIKE @p2 ESCAPE '\' OR u.display_name LIKE @p2 ESCAPE '\'
+                                   OR u.display_name LIKE N'% ' + @p2 ESCAPE '\'))
+                   OR (@p3 <> '' AND u.mobile_normalized = @p3 AND u.is_mobile_verified = 1))
+              AND NOT `+blockedSQL("@p1", "u.id")+`
+        )
+        SELECT TOP (@p5) id, username, display_name, avatar_url, is_bot, contact_rank, match_rank, sort_name
+        FROM matches
+        WHERE @p6 = 0
+           OR contact_rank > @p7
+           OR (contact_rank = @p7 AND (match_rank > @p8
+               OR (match_rank = @p8 AND (sort_name > @p9
+                   OR (sort_name = @p9 AND id > @p10)))))
+        ORDER BY contact_rank, match_rank, sort_name, id
+    `, p.ViewerID, prefix, p.Mobile, p.Prefix, p.Limit,
+		hasCursor, after.ContactRank, after.MatchRank, after.SortName, after.ID)
+	if err != nil {
+		r.errorLogger.Printf("SearchUsers: query failed viewer=%s err=%v", p.ViewerID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []UserSearchHit
+	for rows.Next() {
+		var h UserSearchHit
+		var username, displayName, avatarURL sql.NullString
+		if err := rows.Scan(&h.ID, &username, &displayName, &avatarURL, &h.IsBot,
+			&h.Cursor.ContactRank, &h.Cursor.MatchRank, &h.Cursor.SortName); err != nil {
+			r.errorLogger.Printf("SearchUsers: scan failed viewer=%s err=%v", p.ViewerID, err)
+			return nil, err
+		}
+		h.Username = nullStringPtr(username)
+		h.DisplayName = nullStringPtr(displayName)
+		h.AvatarURL = nullStringPtr(avatarURL)
+		h.IsContact = h.Cursor.ContactRank == 0
+		h.Cursor.ID = h.ID
+		out = append(out, h)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,286 @@
+/* Place: backend/go/repository/session_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"gatherup/models"
+
+	"github.com/google/uuid"
+)
+
+// SessionMeta carries client details recorded on dbo.user_sessions at login.
+type SessionMeta struct {
+	DeviceInfo *string
+	IPAddress  *string
+	Latitude   *float64
+	Longitude  *float64
+}
+
+// StartSession creates a user_sessions row and the first refresh token of its family in one
+// transaction. The returned id is both the session id and the refresh token session_id.
+func (r *UserRepo) StartSession(ctx context.Context, userID, tokenHash string, expiresAt time.Time, meta SessionMeta) (string, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		r.errorLogger.Printf("StartSession: invalid user id format=%q err=%v", userID, err)
+		return "", fmt.Errorf("invalid user id format: %w", err)
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("StartSession: begin tx failed userID=%s err=%v", userID, err)
+		return "", err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	id := uuid.New().String()
+	now := time.Now().UTC()
+
+	if _, err = tx.ExecContext(ctx, `
+        INSERT INTO dbo.user_sessions (id, user_id, device_info, ip_address, session_latitude, session_longitude,
+                                       session_location, created_at, expires_at, last_activity_at, is_revoked, is_deleted)
+        VALUES (@p1, @p2, @p3, @p4, @p5, @p6,
+                CASE WHEN @p5 IS NULL OR @p6 IS NULL THEN NULL ELSE geography::Point(@p5, @p6, 4326) END,
+                @p7, @p8, @p7, 0, 0)
+    `, id, userID, sqlNullString(meta.DeviceInfo), sqlNullString(meta.IPAddress),
+		sqlNullFloat(meta.Latitude), sqlNullFloat(meta.Longitude), now, expiresAt); err != nil {
+		r.errorLogger.Printf("StartSession: insert session failed id=%s userID=%s err=%v", id, userID, err)
+		return "", err
+	}
+
+	if _, err = tx.ExecContext(ctx, `
+        INSERT INTO dbo.refresh_tokens (id, user_id, token_hash, device_info, created_at, expires_at, is_revoked, session_id)
+        VALUES (@p1, @p2, @p3, @p4, @p5, @p6, 0, @p1)
+    `, id, userID, tokenHash, sqlNullString(meta.DeviceInfo), now, expiresAt); err != nil {
+		r.errorLogger.Printf("StartSession: insert refresh token failed id=%s userID=%s err=%v", id, userID, err)
+		return "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("StartSession: commit failed id=%s userID=%s err=%v", id, userID, err)
+		return "", err
+	}
+	r.infoLogger.Printf("StartSession: started id=%s userID=%s", id, userID)
+	return id, nil
+}
+
+// TouchSession records activity on a session after a successful refresh.
+// Sessions created before user_sessions was populated simply match no row.
+func (r *UserRepo) TouchSession(ctx context.Context, sessionID string, ip *string, expiresAt time.Time) error {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return fmt.Errorf("invalid session id: %w", err)
+	}
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.user_sessions
+        SET last_activity_at = SYSDATETIMEOFFSET(), expires_at = @p2, ip_address = COALESCE(@p3, ip_address)
+        WHERE id = @p1 AND is_revoked = 0
+    `, sessionID, expiresAt, sqlNullString(ip))
+	if err != nil {
+		r.errorLogger.Printf("TouchSession: exec failed sessionID=%s err=%v", sessionID, err)
+		return err
+	}
+	return nil
+}
+
+// ListActiveSessions returns the user's non-revoked, non-expired sessions, most recently active first.
+func (r *UserRepo) ListActiveSessions(ctx context.Context, userID string) ([]models.UserSession, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	rows, err := r.db.QueryContext(ctx, `
+        SELECT CONVERT(nvarchar(36), id), device_info, ip_address, session_latitude, session_longitude,
+               created_at, expires_at, last_activity_at
+        FROM dbo.user_sessions
+        WHERE user_id = @p1 AND is_revoked = 0 AND is_deleted = 0
+          AND (expires_at IS NULL OR expires_at > SYSDATETIMEOFFSET())
+        ORDER BY COALESCE(last_activity_at, created_at) DESC
+    `, userID)
+	if err != nil {
+		r.errorLogger.Printf("ListActiveSessions: query failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	var out []models.UserSession
+	for rows.Next() {
+		s := models.UserSession{UserID: userID}
+		var device, ip sql.NullString
+		var lat, lng sql.NullFloat64
+		var expires, lastActivity sql.NullTime
+		if err := rows.Scan(&s.ID, &device, &ip, &lat, &lng, &s.CreatedAt, &expires, &lastActivity); err != nil {
+			r.errorLogger.Printf("ListActiveSessions: scan failed userID=%s err=%v", userID, err)
+			return nil, err
+		}
+		if device.Valid {
+			s.DeviceInfo = &device.String
+		}
+		if ip.Valid {
+			s.IPAddress = &ip.String
+		}
+		if lat.Valid && lng.Valid {
+			s.SessionLatitude = &lat.Float64
+			s.SessionLongitude = &lng.Float64
+		}
+		if expires.Valid {
+			t := expires.Time
+			s.ExpiresAt = &t
+		}
+		if lastActivity.Valid {
+			t := lastActivity.Time
+			s.LastActivityAt = &t
+		}
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		r.errorLogger.Printf("ListActiveSessions: rows failed userID=%s err=%v", userID, err)
+		return nil, err
+	}
+	return out, nil
+}
+
+// RevokeUserSession revokes a session owned by userID. Returns false if no such active session exists.
+func (r *UserRepo) RevokeUserSession(ctx context.Context, userID, sessionID string) (bool, error) {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return false, nil
+	}
+	var owned bool
+	row := r.db.QueryRowContext(ctx, `
+        SELECT CASE WHEN EXISTS (
+            SELECT 1 FROM dbo.user_sessions WHERE id = @p1 AND user_id = @p2 AND is_revoked = 0
+        ) THEN CAST(1 AS bit) ELSE CAST(0 AS bit) END
+    `, sessionID, userID)
+	if err := row.Scan(&owned); err != nil {
+		r.errorLogger.Printf("RevokeUserSession: scan failed sessionID=%s userID=%s err=%v", sessionID, userID, err)
+		return false, err
+	}
+	if !owned {
+		return false, nil
+	}
+	if _, err := r.RevokeSession(ctx, sessionID); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// RevokeSession revokes a session row and every refresh token belonging to it (logout).
+// Returns the number of refresh tokens revoked.
+func (r *UserRepo) RevokeSession(ctx context.Context, sessionID string) (int64, error) {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		r.errorLogger.Printf("RevokeSession: invalid sessionID=%q err=%v", sessionID, err)
+		return 0, fmt.Errorf("invalid session id: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("RevokeSession: begin tx failed sessionID=%s err=%v", sessionID, err)
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+        UPDATE dbo.refresh_tokens SET is_revoked = 1
+        WHERE session_id = @p1 AND is_revoked = 0
+    `, sessionID)
+	if err != nil {
+		r.errorLogger.Printf("RevokeSession: revoke tokens failed sessionID=%s err=%v", sessionID, err)
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE dbo.user_sessions SET is_revoked = 1 WHERE id = @p1`, sessionID); err != nil {
+		r.errorLogger.Printf("RevokeSession: revoke session failed sessionID=%s err=%v", sessionID, err)
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("RevokeSession: commit failed sessionID=%s err=%v", sessionID, err)
+		return 0, err
+	}
+	n, _ := res.RowsAffected()
+	r.infoLogger.Printf("RevokeSession: sessionID=%s revoked=%d", sessionID, n)
+	return n, nil
+}
+
+// RevokeAllRefreshTokens revokes every non-revoked refresh token and session of a user (logout everywhere).
+// Returns the number of tokens revoked.
+func (r *UserRepo) RevokeAllRefreshTokens(ctx context.Context, userID string) (int64, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		r.errorLogger.Printf("RevokeAllRefreshTokens: invalid userID=%q err=%v", userID, err)
+		return 0, fmt.Errorf("invalid user id: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("RevokeAllRefreshTokens: begin tx failed userID=%s err=%v", userID, err)
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	n, err := revokeAllSessions(ctx, tx, userID)
+	if err != nil {
+		r.errorLogger.Printf("RevokeAllRefreshTokens: revoke failed userID=%s err=%v", userID, err)
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("RevokeAllRefreshTokens: commit failed userID=%s err=%v", userID, err)
+		return 0, err
+	}
+	r.infoLogger.Printf("RevokeAllRefreshTokens: userID=%s revoked=%d", userID, n)
+	return n, nil
+}
+
+// RevokeAllAccess is RevokeAllRefreshTokens plus the API keys of the user and their bots, in
+// one transaction (logout everywhere). Returns the number of refresh tokens revoked.
+func (r *UserRepo) RevokeAllAccess(ctx context.Context, userID string) (int64, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		r.errorLogger.Printf("RevokeAllAccess: invalid userID=%q err=%v", userID, err)
+		return 0, fmt.Errorf("invalid user id: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("RevokeAllAccess: begin tx failed userID=%s err=%v", userID, err)
+		return 0, err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	n, err := revokeAllSessions(ctx, tx, userID)
+	if err != nil {
+		r.errorLogger.Printf("RevokeAllAccess: revoke sessions failed userID=%s err=%v", userID, err)
+		return 0, err
+	}
+	keys, err := revokeAllAPIKeys(ctx, tx, userID)
+	if err != nil {
+		r.errorLogger.Printf("RevokeAllAccess: revoke api keys failed userID=%s err=%v", userID, err)
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("RevokeAllAccess: commit failed userID=%s err=%v", userID, err)
+		return 0, err
+	}
+	r.infoLogger.Printf("RevokeAllAccess: userID=%s revoked=%d api_keys=%d", userID, n, keys)
+	return n, nil
+}
+
+// revokeAllSessions revokes every refresh token and session of userID inside the caller's
+// transaction and returns the number of tokens revoked.
+func revokeAllSessions(ctx context.Context, ex execer, userID string) (int64, error) {
+	res, err := ex.ExecContext(ctx, `
+        UPDATE dbo.refresh_tokens SET is_revoked = 1
+        WHERE user_id = @p1 AND is_revoked = 0
+    `, userID)
+	if err != nil {
+		return 0, err
+	}
+	if _, err := ex.ExecContext(ctx, `
+        UPDATE dbo.user_sessions SET is_revoked = 1
+        WHERE user_id = @p1 AND is_revoked = 0
+    `, userID); err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
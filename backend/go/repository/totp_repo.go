@@ -0,0 +1,243 @@
+/* Place: backend/go/repository/totp_repo.go */
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TOTPCredential is a totp / totp_pending row; Secret is the sealed (encrypted) seed.
+type TOTPCredential struct {
+	ID       int64
+	Secret   string
+	LastUsed *time.Time
+}
+
+// GetTOTPCredential returns the user's credential of type CredentialTOTP or CredentialTOTPPending.
+// Returns nil, nil if none exists.
+func (r *UserRepo) GetTOTPCredential(ctx context.Context, userID, credentialType string) (*TOTPCredential, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	var c TOTPCredential
+	var lastUsed sql.NullTime
+	row := r.db.QueryRowContext(ctx, `
+        SELECT TOP 1 id, password_hash, last_used
+        FROM dbo.user_credentials
+        WHERE user_id = @p1 AND credential_type = @p2 AND is_deleted = 0
+        ORDER BY created_at DESC
+    `, userID, credentialType)
+	if err := row.Scan(&c.ID, &c.Secret, &lastUsed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, nil
+		}
+		r.errorLogger.Printf("GetTOTPCredential: scan failed userID=%s type=%s err=%v", userID, credentialType, err)
+		return nil, err
+	}
+	if lastUsed.Valid {
+		t := lastUsed.Time
+		c.LastUsed = &t
+	}
+	return &c, nil
+}
+
+// SavePendingTOTP replaces any unconfirmed enrollment of the user with a new sealed secret.
+func (r *UserRepo) SavePendingTOTP(ctx context.Context, userID, sealedSecret string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("SavePendingTOTP: begin tx failed userID=%s err=%v", userID, err)
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now().UTC()
+	if _, err = tx.ExecContext(ctx, `
+        UPDATE dbo.user_credentials SET is_deleted = 1, deleted_at = @p3
+        WHERE user_id = @p1 AND credential_type = @p2 AND is_deleted = 0
+    `, userID, CredentialTOTPPending, now); err != nil {
+		r.errorLogger.Printf("SavePendingTOTP: clear pending failed userID=%s err=%v", userID, err)
+		return err
+	}
+	if _, err = tx.ExecContext(ctx, `
+        INSERT INTO dbo.user_credentials (user_id, credential_type, password_hash, created_at, is_deleted)
+        VALUES (@p1, @p2, @p3, @p4, 0)
+    `, userID, CredentialTOTPPending, sealedSecret, now); err != nil {
+		r.errorLogger.Printf("SavePendingTOTP: insert failed userID=%s err=%v", userID, err)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("SavePendingTOTP: commit failed userID=%s err=%v", userID, err)
+		return err
+	}
+	return nil
+}
+
+// ActivateTOTP promotes the pending enrollment to the active TOTP credential and stores a fresh
+// set of recovery code hashes. step is recorded as used so the confirming code can't be replayed.
+func (r *UserRepo) ActivateTOTP(ctx context.Context, userID string, pendingID int64, step time.Time, recoveryHashes []string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("ActivateTOTP: begin tx failed userID=%s err=%v", userID, err)
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now().UTC()
+	if _, err = tx.ExecContext(ctx, `
+        UPDATE dbo.user_credentials SET is_deleted = 1, deleted_at = @p4
+        WHERE user_id = @p1 AND credential_type IN (@p2, @p3) AND is_deleted = 0
+    `, userID, CredentialTOTP, CredentialRecoveryCode, now); err != nil {
+		r.errorLogger.Printf("ActivateTOTP: clear previous failed userID=%s err=%v", userID, err)
+		return err
+	}
+	res, err := tx.ExecContext(ctx, `
+        UPDATE dbo.user_credentials SET credential_type = @p3, last_used = @p4
+        WHERE id = @p1 AND user_id = @p2 AND credential_type = @p5 AND is_deleted = 0
+    `, pendingID, userID, CredentialTOTP, step, CredentialTOTPPending)
+	if err != nil {
+		r.errorLogger.Printf("ActivateTOTP: promote failed userID=%s err=%v", userID, err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	if err = insertRecoveryCodes(ctx, tx, userID, recoveryHashes, now); err != nil {
+		r.errorLogger.Printf("ActivateTOTP: insert recovery codes failed userID=%s err=%v", userID, err)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("ActivateTOTP: commit failed userID=%s err=%v", userID, err)
+		return err
+	}
+	r.infoLogger.Printf("ActivateTOTP: enabled userID=%s", userID)
+	return nil
+}
+
+// MarkTOTPStepUsed records step as the last accepted TOTP step. It returns false if the same
+// or a later step was already used, which makes each code single-use.
+func (r *UserRepo) MarkTOTPStepUsed(ctx context.Context, credentialID int64, step time.Time) (bool, error) {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.user_credentials SET last_used = @p2
+        WHERE id = @p1 AND is_deleted = 0 AND (last_used IS NULL OR last_used < @p2)
+    `, credentialID, step)
+	if err != nil {
+		r.errorLogger.Printf("MarkTOTPStepUsed: update failed id=%d err=%v", credentialID, err)
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	return n > 0, nil
+}
+
+// ConsumeRecoveryCode deletes the matching unused recovery code; false if there is none.
+func (r *UserRepo) ConsumeRecoveryCode(ctx context.Context, userID, codeHash string) (bool, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return false, fmt.Errorf("invalid user id: %w", err)
+	}
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.user_credentials SET is_deleted = 1, deleted_at = SYSDATETIMEOFFSET(), last_used = SYSDATETIMEOFFSET()
+        WHERE user_id = @p1 AND credential_type = @p2 AND password_hash = @p3 AND is_deleted = 0
+    `, userID, CredentialRecoveryCode, codeHash)
+	if err != nil {
+		r.errorLogger.Printf("ConsumeRecoveryCode: update failed userID=%s err=%v", userID, err)
+		return false, err
+	}
+	n, _ := res.RowsAffected()
+	if n > 0 {
+		r.infoLogger.Printf("ConsumeRecoveryCode: used userID=%s", userID)
+	}
+	return n > 0, nil
+}
+
+// ReplaceRecoveryCodes invalidates all recovery codes of the user and stores new hashes.
+func (r *UserRepo) ReplaceRecoveryCodes(ctx context.Context, userID string, hashes []string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("ReplaceRecoveryCodes: begin tx failed userID=%s err=%v", userID, err)
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	now := time.Now().UTC()
+	if _, err = tx.ExecContext(ctx, `
+        UPDATE dbo.user_credentials SET is_deleted = 1, deleted_at = @p3
+        WHERE user_id = @p1 AND credential_type = @p2 AND is_deleted = 0
+    `, userID, CredentialRecoveryCode, now); err != nil {
+		r.errorLogger.Printf("ReplaceRecoveryCodes: clear failed userID=%s err=%v", userID, err)
+		return err
+	}
+	if err = insertRecoveryCodes(ctx, tx, userID, hashes, now); err != nil {
+		r.errorLogger.Printf("ReplaceRecoveryCodes: insert failed userID=%s err=%v", userID, err)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("ReplaceRecoveryCodes: commit failed userID=%s err=%v", userID, err)
+		return err
+	}
+	return nil
+}
+
+func insertRecoveryCodes(ctx context.Context, tx *sql.Tx, userID string, hashes []string, now time.Time) error {
+	for _, h := range hashes {
+		if _, err := tx.ExecContext(ctx, `
+            INSERT INTO dbo.user_credentials (user_id, credential_type, password_hash, created_at, is_deleted)
+            VALUES (@p1, @p2, @p3, @p4, 0)
+        `, userID, CredentialRecoveryCode, h, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CountCredentials returns how many live credentials of credentialType the user has.
+func (r *UserRepo) CountCredentials(ctx context.Context, userID, credentialType string) (int, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return 0, fmt.Errorf("invalid user id: %w", err)
+	}
+	var n int
+	err := r.db.QueryRowContext(ctx, `
+        SELECT COUNT(*) FROM dbo.user_credentials
+        WHERE user_id = @p1 AND credential_type = @p2 AND is_deleted = 0
+    `, userID, credentialType).Scan(&n)
+	if err != nil {
+		r.errorLogger.Printf("CountCredentials: query failed userID=%s type=%s err=%v", userID, credentialType, err)
+		return 0, err
+	}
+	return n, nil
+}
+
+// DisableTOTP removes the TOTP secret, any pending enrollment and all recovery codes.
+func (r *UserRepo) DisableTOTP(ctx context.Context, userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.user_credentials SET is_deleted = 1, deleted_at = SYSDATETIMEOFFSET()
+        WHERE user_id = @p1 AND credential_type IN (@p2, @p3, @p4) AND is_deleted = 0
+    `, userID, CredentialTOTP, CredentialTOTPPending, CredentialRecoveryCode)
+	if err != nil {
+		r.errorLogger.Printf("DisableTOTP: update failed userID=%s err=%v", userID, err)
+		return err
+	}
+	r.infoLogger.Printf("DisableTOTP: disabled userID=%s", userID)
+	return nil
+}
@@ -28,13 +28,20 @@ type UserRepo struct {
 type RefreshTokenRow struct {
 	ID        string
 	UserID    string
+	SessionID string
 	TokenHash string
 	Device    *string
 	CreatedAt time.Time
 	ExpiresAt time.Time
 	Revoked   bool
+	// Rotated is true when a newer token in the family was issued from this one.
+	Rotated bool
 }
 
+// ErrRefreshTokenRevoked is returned by RotateRefreshToken when the old token was
+// revoked concurrently (another request rotated it first).
+var ErrRefreshTokenRevoked = errors.New("refresh token already revoked")
+
 // NewUserRepo constructs a UserRepo. You may provide nil loggers to use defaults
 // (default: info -> stdout, error -> logs/error.log + stdout).
 // Place: call this from bootstrap (backend/go/cmd/server/main.go) instead of the old constructor.
@@ -193,16 +200,24 @@ func (r *UserRepo) GetByID(ctx context.Context, id string) (*models.User, error)
 	// request canonical string form for id to avoid driver raw-bytes
 	row := r.db.QueryRowContext(ctx, `
         SELECT CONVERT(nvarchar(36), id) as id,
-               mobile_number, mobile_normalized, email, username, created_at, updated_at, is_deleted
+               mobile_number, mobile_normalized, country_code, display_name, avatar_url, bio, email, username,
+               latitude, longitude, location_updated_at, date_of_birth, gender,
+               is_mobile_verified, mobile_verified_at, is_email_verified, is_active, is_bot,
+               CONVERT(nvarchar(36), bot_owner_id), username_changed_at, created_at, updated_at, is_deleted, rv
         FROM dbo.users WHERE id = @p1 AND is_deleted = 0
     `, id)
 
 	u := &models.User{}
 	var idStr sql.NullString
-	var mobile, mobileNorm, email, username sql.NullString
-	var updatedAt sql.NullTime
-
-	if err := row.Scan(&idStr, &mobile, &mobileNorm, &email, &username, &u.CreatedAt, &updatedAt, &u.IsDeleted); err != nil {
+	var mobile, mobileNorm, countryCode, displayName, avatarURL, bio, email, username, gender, botOwner sql.NullString
+	var lat, lng sql.NullFloat64
+	var updatedAt, mobileVerifiedAt, locationUpdatedAt, dateOfBirth, usernameChangedAt sql.NullTime
+	var emailVerified sql.NullBool
+
+	if err := row.Scan(&idStr, &mobile, &mobileNorm, &countryCode, &displayName, &avatarURL, &bio, &email, &username,
+		&lat, &lng, &locationUpdatedAt, &dateOfBirth, &gender,
+		&u.IsMobileVerified, &mobileVerifiedAt, &emailVerified, &u.IsActive, &u.IsBot,
+		&botOwner, &usernameChangedAt, &u.CreatedAt, &updatedAt, &u.IsDeleted, &u.Rv); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			r.infoLogger.Printf("GetByID: not found id=%s", id)
 			return nil, nil
@@ -229,15 +244,25 @@ func (r *UserRepo) GetByID(ctx context.Context, id string) (*models.User, error)
 	if mobileNorm.Valid {
 		u.MobileNormalized = &mobileNorm.String
 	}
-	if email.Valid {
-		u.Email = &email.String
-	}
-	if username.Valid {
-		u.Username = &username.String
-	}
-	if updatedAt.Valid {
-		t := updatedAt.Time
-		u.UpdatedAt = &t
+	u.CountryCode = nullStringPtr(countryCode)
+	u.DisplayName = nullStringPtr(displayName)
+	u.AvatarURL = nullStringPtr(avatarURL)
+	u.Bio = nullStringPtr(bio)
+	u.Email = nullStringPtr(email)
+	u.Username = nullStringPtr(username)
+	u.Gender = nullStringPtr(gender)
+	u.BotOwnerID = nullStringPtr(botOwner)
+	if lat.Valid && lng.Valid {
+		u.Latitude, u.Longitude = &lat.Float64, &lng.Float64
+	}
+	u.LocationUpdatedAt = nullTimePtr(locationUpdatedAt)
+	u.DateOfBirth = nullTimePtr(dateOfBirth)
+	u.UsernameChangedAt = nullTimePtr(usernameChangedAt)
+	u.UpdatedAt = nullTimePtr(updatedAt)
+	u.MobileVerifiedAt = nullTimePtr(mobileVerifiedAt)
+	if emailVerified.Valid {
+		v := emailVerified.Bool
+		u.IsEmailVerified = &v
 	}
 
 	r.infoLogger.Printf("GetByID: found id=%s", u.ID)
@@ -282,7 +307,122 @@ func (r *UserRepo) GetCredentialByIdentifier(ctx context.Context, identifier str
 	return userID, pwHash, nil
 }
 
-// SaveRefreshToken stores refresh token hash. Validates userID before DB write.
+// TouchCredentialLastUsed records a successful use of a credential.
+func (r *UserRepo) TouchCredentialLastUsed(ctx context.Context, userID, credentialType string) error {
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.user_credentials SET last_used = SYSDATETIMEOFFSET()
+        WHERE user_id = @p1 AND credential_type = @p2 AND is_deleted = 0
+    `, userID, credentialType)
+	if err != nil {
+		r.errorLogger.Printf("TouchCredentialLastUsed: exec failed userID=%s type=%s err=%v", userID, credentialType, err)
+	}
+	return err
+}
+
+// GetPasswordHashByUserID returns the password credential hash of a user ("" if none).
+func (r *UserRepo) GetPasswordHashByUserID(ctx context.Context, userID string) (string, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return "", fmt.Errorf("invalid user id: %w", err)
+	}
+	var ph sql.NullString
+	row := r.db.QueryRowContext(ctx, `
+        SELECT TOP 1 password_hash FROM dbo.user_credentials
+        WHERE user_id = @p1 AND credential_type = 'password' AND is_deleted = 0
+    `, userID)
+	if err := row.Scan(&ph); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", nil
+		}
+		r.errorLogger.Printf("GetPasswordHashByUserID: scan failed userID=%s err=%v", userID, err)
+		return "", err
+	}
+	return ph.String, nil
+}
+
+// UpdatePasswordHash replaces the password hash of the user's password credential and, in the
+// same transaction, revokes every refresh token, session and API key (bots included) of the
+// user, so the new password never coexists with access granted under the old one.
+func (r *UserRepo) UpdatePasswordHash(ctx context.Context, userID, passwordHash string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		r.errorLogger.Printf("UpdatePasswordHash: begin tx failed userID=%s err=%v", userID, err)
+		return err
+	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
+
+	res, err := tx.ExecContext(ctx, `
+        UPDATE dbo.user_credentials SET password_hash = @p2
+        WHERE user_id = @p1 AND credential_type = 'password' AND is_deleted = 0
+    `, userID, passwordHash)
+	if err != nil {
+		r.errorLogger.Printf("UpdatePasswordHash: exec failed userID=%s err=%v", userID, err)
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		r.errorLogger.Printf("UpdatePasswordHash: no password credential userID=%s", userID)
+		return fmt.Errorf("no password credential for user")
+	}
+	revoked, err := revokeAllSessions(ctx, tx, userID)
+	if err != nil {
+		r.errorLogger.Printf("UpdatePasswordHash: revoke sessions failed userID=%s err=%v", userID, err)
+		return err
+	}
+	keys, err := revokeAllAPIKeys(ctx, tx, userID)
+	if err != nil {
+		r.errorLogger.Printf("UpdatePasswordHash: revoke api keys failed userID=%s err=%v", userID, err)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		r.errorLogger.Printf("UpdatePasswordHash: commit failed userID=%s err=%v", userID, err)
+		return err
+	}
+	r.infoLogger.Printf("UpdatePasswordHash: updated userID=%s revoked=%d api_keys=%d", userID, revoked, keys)
+	return nil
+}
+
+// ReplacePasswordHash swaps the password hash only if it still equals oldHash (hash upgrades
+// must not clobber a concurrent password change).
+func (r *UserRepo) ReplacePasswordHash(ctx context.Context, userID, oldHash, newHash string) error {
+	res, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.user_credentials SET password_hash = @p3
+        WHERE user_id = @p1 AND credential_type = 'password' AND password_hash = @p2 AND is_deleted = 0
+    `, userID, oldHash, newHash)
+	if err != nil {
+		r.errorLogger.Printf("ReplacePasswordHash: exec failed userID=%s err=%v", userID, err)
+		return err
+	}
+	n, _ := res.RowsAffected()
+	r.infoLogger.Printf("ReplacePasswordHash: userID=%s upgraded=%v", userID, n > 0)
+	return nil
+}
+
+// MarkMobileVerified flags the user's mobile number as verified (idempotent).
+func (r *UserRepo) MarkMobileVerified(ctx context.Context, userID string) error {
+	if _, err := uuid.Parse(userID); err != nil {
+		return fmt.Errorf("invalid user id: %w", err)
+	}
+	_, err := r.db.ExecContext(ctx, `
+        UPDATE dbo.users
+        SET is_mobile_verified = 1, mobile_verified_at = COALESCE(mobile_verified_at, SYSDATETIMEOFFSET()),
+            updated_at = SYSDATETIMEOFFSET()
+        WHERE id = @p1 AND is_deleted = 0
+    `, userID)
+	if err != nil {
+		r.errorLogger.Printf("MarkMobileVerified: exec failed userID=%s err=%v", userID, err)
+		return err
+	}
+	r.infoLogger.Printf("MarkMobileVerified: userID=%s", userID)
+	return nil
+}
+
+// SaveRefreshToken stores refresh token hash as the first token of a new session.
+// The returned id doubles as the session id carried forward by RotateRefreshToken.
+// Validates userID before DB write.
 func (r *UserRepo) SaveRefreshToken(ctx context.Context, userID, tokenHash string, device *string, expiresAt time.Time) (string, error) {
 	// validate userID is a UUID before DB write
 	if _, err := uuid.Parse(userID); err != nil {
@@ -298,8 +438,8 @@ func (r *UserRepo) SaveRefreshToken(ctx context.Context, userID, tokenHash strin
 		id, userID, device != nil && *device != "", expiresAt)
 
 	_, err := r.db.ExecContext(ctx, `
-        INSERT INTO dbo.refresh_tokens (id, user_id, token_hash, device_info, created_at, expires_at, is_revoked)
-        VALUES (@p1, @p2, @p3, @p4, @p5, @p6, 0)
+        INSERT INTO dbo.refresh_tokens (id, user_id, token_hash, device_info, created_at, expires_at, is_revoked, session_id)
+        VALUES (@p1, @p2, @p3, @p4, @p5, @p6, 0, @p1)
     `, id, userID, tokenHash, device, now, expiresAt)
 	if err != nil {
 		r.errorLogger.Printf("SaveRefreshToken: exec failed id=%s userID=%s err=%v", id, userID, err)
@@ -316,16 +456,20 @@ func (r *UserRepo) GetRefreshTokenRow(ctx context.Context, tokenHash string) (*R
 	row := r.db.QueryRowContext(ctx, `
         SELECT CONVERT(nvarchar(36), id) as id,
                CONVERT(nvarchar(36), user_id) as user_id,
-               token_hash, device_info, created_at, expires_at, is_revoked
-        FROM dbo.refresh_tokens WHERE token_hash = @p1
+               CONVERT(nvarchar(36), COALESCE(session_id, id)) as session_id,
+               token_hash, device_info, created_at, expires_at, is_revoked,
+               CASE WHEN EXISTS (SELECT 1 FROM dbo.refresh_tokens c WHERE c.parent_id = t.id)
+                    THEN CAST(1 AS bit) ELSE CAST(0 AS bit) END as rotated
+        FROM dbo.refresh_tokens t WHERE token_hash = @p1
     `, tokenHash)
 
 	var rr RefreshTokenRow
 	var device sql.NullString
 	var uid sql.NullString
 	var idStr sql.NullString
+	var sid sql.NullString
 
-	if err := row.Scan(&idStr, &uid, &rr.TokenHash, &device, &rr.CreatedAt, &rr.ExpiresAt, &rr.Revoked); err != nil {
+	if err := row.Scan(&idStr, &uid, &sid, &rr.TokenHash, &device, &rr.CreatedAt, &rr.ExpiresAt, &rr.Revoked, &rr.Rotated); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			r.infoLogger.Printf("GetRefreshTokenRow: not found tokenHashLen=%d", len(tokenHash))
 			return nil, nil
@@ -339,6 +483,7 @@ func (r *UserRepo) GetRefreshTokenRow(ctx context.Context, tokenHash string) (*R
 		return nil, fmt.Errorf("refresh token row missing id")
 	}
 	rr.ID = idStr.String
+	rr.SessionID = sid.String
 
 	if device.Valid {
 		rr.Device = &device.String
@@ -381,7 +526,9 @@ func (r *UserRepo) RevokeRefreshToken(ctx context.Context, id string) error {
 	return nil
 }
 
-// RotateRefreshToken revokes old and inserts a new token in a transaction
+// RotateRefreshToken revokes old and inserts a new token in a transaction.
+// The new token inherits user, session (family) and device_info and records the old one as parent.
+// Returns ErrRefreshTokenRevoked if the old token was no longer active.
 func (r *UserRepo) RotateRefreshToken(ctx context.Context, oldID, newTokenHash string, newExpiry time.Time) (string, error) {
 	// validate IDs
 	if _, err := uuid.Parse(oldID); err != nil {
@@ -401,15 +548,21 @@ func (r *UserRepo) RotateRefreshToken(ctx context.Context, oldID, newTokenHash s
 		}
 	}()
 
-	if _, err := tx.ExecContext(ctx, `UPDATE dbo.refresh_tokens SET is_revoked = 1 WHERE id = @p1`, oldID); err != nil {
+	res, err := tx.ExecContext(ctx, `UPDATE dbo.refresh_tokens SET is_revoked = 1 WHERE id = @p1 AND is_revoked = 0`, oldID)
+	if err != nil {
 		r.errorLogger.Printf("RotateRefreshToken: revoke old failed oldID=%s err=%v", oldID, err)
 		return "", err
 	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		r.infoLogger.Printf("RotateRefreshToken: oldID=%s already revoked", oldID)
+		return "", ErrRefreshTokenRevoked
+	}
 	newID := uuid.New().String()
 	// use CONVERT to ensure user_id is inserted as canonical nvarchar(36)
 	if _, err := tx.ExecContext(ctx, `
-        INSERT INTO dbo.refresh_tokens (id, user_id, token_hash, created_at, expires_at, is_revoked)
-        SELECT @p1, CONVERT(nvarchar(36), user_id), @p2, SYSUTCDATETIME(), @p3, 0 FROM dbo.refresh_tokens WHERE id = @p4
+        INSERT INTO dbo.refresh_tokens (id, user_id, token_hash, device_info, created_at, expires_at, is_revoked, session_id, parent_id)
+        SELECT @p1, CONVERT(nvarchar(36), user_id), @p2, device_info, SYSUTCDATETIME(), @p3, 0, COALESCE(session_id, id), id
+        FROM dbo.refresh_tokens WHERE id = @p4
     `, newID, newTokenHash, newExpiry, oldID); err != nil {
 		r.errorLogger.Printf("RotateRefreshToken: insert new failed oldID=%s newID=%s err=%v", oldID, newID, err)
 		return "", err
@@ -423,6 +576,25 @@ func (r *UserRepo) RotateRefreshToken(ctx context.Context, oldID, newTokenHash s
 	return newID, nil
 }
 
+// IsSessionActive reports whether a session still has a non-revoked, non-expired refresh token.
+func (r *UserRepo) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	if _, err := uuid.Parse(sessionID); err != nil {
+		return false, nil
+	}
+	var active bool
+	row := r.db.QueryRowContext(ctx, `
+        SELECT CASE WHEN EXISTS (
+            SELECT 1 FROM dbo.refresh_tokens
+            WHERE session_id = @p1 AND is_revoked = 0 AND expires_at > SYSDATETIMEOFFSET()
+        ) THEN CAST(1 AS bit) ELSE CAST(0 AS bit) END
+    `, sessionID)
+	if err := row.Scan(&active); err != nil {
+		r.errorLogger.Printf("IsSessionActive: scan failed sessionID=%s err=%v", sessionID, err)
+		return false, err
+	}
+	return active, nil
+}
+
 /* helper for optional sql.NullString building from *string */
 func sqlNullString(p *string) interface{} {
 	if p == nil {
@@ -430,3 +602,29 @@ func sqlNullString(p *string) interface{} {
 	}
 	return *p
 }
+
+/* helper for optional DECIMAL params from *float64 */
+func sqlNullFloat(p *float64) interface{} {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+/* helper for optional *string results from sql.NullString */
+func nullStringPtr(v sql.NullString) *string {
+	if !v.Valid {
+		return nil
+	}
+	s := v.String
+	return &s
+}
+
+/* helper for optional *time.Time results from sql.NullTime */
+func nullTimePtr(v sql.NullTime) *time.Time {
+	if !v.Valid {
+		return nil
+	}
+	t := v.Time
+	return &t
+}
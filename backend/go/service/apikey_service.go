@@ -0,0 +1,202 @@
+/* Place: backend/go/service/apikey_service.go */
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"gatherup/auth"
+	"gatherup/models"
+	"gatherup/repository"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// APIKeyConfig bounds key lifetimes and counts.
+type APIKeyConfig struct {
+	DefaultTTL time.Duration
+	MaxTTL     time.Duration
+	// MaxPerUser limits live keys per account (personal or bot).
+	MaxPerUser int
+	// TouchInterval throttles last_used_at writes for busy keys.
+	TouchInterval time.Duration
+}
+
+// APIKeyService mints and verifies scoped API keys for users and their bot accounts.
+type APIKeyService struct {
+	keys  *repository.APIKeyRepo
+	users *repository.UserRepo
+	cfg   *APIKeyConfig
+}
+
+func NewAPIKeyService(keys *repository.APIKeyRepo, users *repository.UserRepo, cfg *APIKeyConfig) *APIKeyService {
+	return &APIKeyService{keys: keys, users: users, cfg: cfg}
+}
+
+var ErrAPIKeyInvalid = errors.New("invalid or expired api key")
+var ErrAPIKeyNotFound = errors.New("api key not found")
+var ErrAPIKeyName = errors.New("name must be 1-100 characters")
+var ErrInvalidScope = errors.New("unknown or missing scope")
+var ErrAPIKeyTTL = errors.New("expiry exceeds the maximum key lifetime")
+var ErrAPIKeyLimit = errors.New("too many active api keys")
+var ErrBotNotFound = errors.New("bot not found")
+var ErrBotDisplayName = errors.New("display_name must be 1-200 characters")
+var ErrUsernameTaken = errors.New("username is already taken")
+
+// CreateKey mints a key for ownerID on behalf of actorID (the same user, or the owner of bot
+// ownerID). ttl 0 means the default lifetime. The raw key is returned once and never stored.
+func (s *APIKeyService) CreateKey(ctx context.Context, actorID, ownerID, name string, scopes []string, ttl time.Duration) (string, *models.APIKey, error) {
+	if err := s.authorizeOwner(ctx, actorID, ownerID); err != nil {
+		return "", nil, err
+	}
+	name = strings.TrimSpace(name)
+	if n := len([]rune(name)); n == 0 || n > 100 {
+		return "", nil, ErrAPIKeyName
+	}
+	scopes, err := normalizeScopes(scopes)
+	if err != nil {
+		return "", nil, err
+	}
+	if ttl == 0 {
+		ttl = s.cfg.DefaultTTL
+	}
+	if ttl < 0 || ttl > s.cfg.MaxTTL {
+		return "", nil, ErrAPIKeyTTL
+	}
+	n, err := s.keys.CountActive(ctx, ownerID)
+	if err != nil {
+		return "", nil, err
+	}
+	if s.cfg.MaxPerUser > 0 && n >= s.cfg.MaxPerUser {
+		return "", nil, ErrAPIKeyLimit
+	}
+
+	raw, hash, prefix, err := auth.GenerateAPIKey()
+	if err != nil {
+		return "", nil, err
+	}
+	k := &models.APIKey{
+		UserID:    ownerID,
+		Name:      name,
+		Prefix:    prefix,
+		Scopes:    scopes,
+		CreatedBy: &actorID,
+		ExpiresAt: time.Now().UTC().Add(ttl),
+	}
+	if err := s.keys.Create(ctx, k, hash); err != nil {
+		return "", nil, err
+	}
+	return raw, k, nil
+}
+
+// ListKeys returns ownerID's keys (without secrets) if actorID may manage them.
+func (s *APIKeyService) ListKeys(ctx context.Context, actorID, ownerID string) ([]models.APIKey, error) {
+	if err := s.authorizeOwner(ctx, actorID, ownerID); err != nil {
+		return nil, err
+	}
+	return s.keys.ListByUser(ctx, ownerID)
+}
+
+// RevokeKey revokes one of ownerID's keys if actorID may manage them.
+func (s *APIKeyService) RevokeKey(ctx context.Context, actorID, ownerID, keyID string) error {
+	if err := s.authorizeOwner(ctx, actorID, ownerID); err != nil {
+		return err
+	}
+	ok, err := s.keys.Revoke(ctx, ownerID, keyID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// CreateBot creates a bot account owned by ownerID. A username, if given, follows the same
+// rules as for people (see ValidateUsername).
+func (s *APIKeyService) CreateBot(ctx context.Context, ownerID, displayName, username string) (string, error) {
+	displayName = strings.TrimSpace(displayName)
+	if n := len([]rune(displayName)); n == 0 || n > 200 {
+		return "", ErrBotDisplayName
+	}
+	var uname *string
+	if username = strings.TrimSpace(username); username != "" {
+		if err := ValidateUsername(username); err != nil {
+			return "", err
+		}
+		uname = &username
+	}
+	id, err := s.users.CreateBotUser(ctx, ownerID, &displayName, uname)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return "", ErrUsernameTaken
+	}
+	return id, err
+}
+
+// ListBots returns the bots owned by ownerID.
+func (s *APIKeyService) ListBots(ctx context.Context, ownerID string) ([]models.User, error) {
+	return s.users.ListBots(ctx, ownerID)
+}
+
+// authorizeOwner allows actors to manage their own keys and those of bots they own.
+func (s *APIKeyService) authorizeOwner(ctx context.Context, actorID, ownerID string) error {
+	if actorID == ownerID {
+		return nil
+	}
+	owner, err := s.users.GetBotOwner(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	if owner == "" || owner != actorID {
+		return ErrBotNotFound
+	}
+	return nil
+}
+
+// Verify resolves a raw API key to claims usable in place of access token claims.
+// Keys carry only RoleUser and their scopes.
+func (s *APIKeyService) Verify(ctx context.Context, raw string) (*auth.Claims, error) {
+	k, err := s.keys.GetActiveByHash(ctx, auth.HashRefreshToken(raw))
+	if err != nil {
+		return nil, err
+	}
+	if k == nil {
+		return nil, ErrAPIKeyInvalid
+	}
+	if k.LastUsedAt == nil || time.Since(*k.LastUsedAt) > s.cfg.TouchInterval {
+		// best-effort: failures are logged by the repo
+		_ = s.keys.TouchLastUsed(ctx, k.ID)
+	}
+	return &auth.Claims{
+		UserID:   k.UserID,
+		Roles:    []string{auth.RoleUser},
+		Scopes:   k.Scopes,
+		APIKeyID: k.ID,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject:   k.UserID,
+			ExpiresAt: jwt.NewNumericDate(k.ExpiresAt),
+		},
+	}, nil
+}
+
+// normalizeScopes validates and de-duplicates requested scopes.
+func normalizeScopes(scopes []string) ([]string, error) {
+	seen := map[string]bool{}
+	var out []string
+	for _, sc := range scopes {
+		sc = strings.TrimSpace(sc)
+		if !auth.IsValidScope(sc) {
+			return nil, ErrInvalidScope
+		}
+		if !seen[sc] {
+			seen[sc] = true
+			out = append(out, sc)
+		}
+	}
+	if len(out) == 0 {
+		return nil, ErrInvalidScope
+	}
+	return out, nil
+}
@@ -3,33 +3,106 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"strings"
+	"sync"
 	"time"
 
 	"gatherup/auth"
+	"gatherup/geo"
+	"gatherup/models"
 	"gatherup/repository"
 )
 
 // Exported config so other packages (cmd/server) can construct it.
 type AuthConfig struct {
-	BcryptCost        int
+	BcryptCost int
+	// PasswordHasher hashes new passwords; nil means bcrypt at BcryptCost.
+	PasswordHasher    auth.PasswordHasher
 	RefreshTokenBytes int
 	RefreshTTL        time.Duration
+	// RequireMobileVerification rejects logins of accounts whose mobile is not OTP-verified.
+	RequireMobileVerification bool
+	// IdentifierGuard / IPGuard throttle failed logins per credential identifier and per client IP.
+	IdentifierGuard LoginGuardConfig
+	IPGuard         LoginGuardConfig
+	// TOTP two-factor: issuer shown in authenticator apps, box encrypting stored secrets,
+	// lifetime of the challenge token between password and code, recovery codes per set.
+	TOTPIssuer        string
+	SecretBox         *auth.SecretBox
+	MFAChallengeTTL   time.Duration
+	RecoveryCodeCount int
+	// PasswordlessLogin enables one-time-code login (RequestLoginOTP / LoginWithOTP).
+	PasswordlessLogin bool
+	// DeletionGracePeriod is how long a deleted account can still be restored by logging in.
+	DeletionGracePeriod time.Duration
+	// ImpersonationTTL is the lifetime of admin impersonation tokens.
+	ImpersonationTTL time.Duration
 }
 
 type AuthService struct {
 	repo       *repository.UserRepo
+	audit      *repository.AuditRepo
+	otp        *OTPService
 	jwtManager *auth.JWTManager
 	cfg        *AuthConfig
+
+	hasher  auth.PasswordHasher
+	idGuard *LoginGuard
+	ipGuard *LoginGuard
+
+	dummyOnce sync.Once
+	dummyHash string
 }
 
-func NewAuthService(repo *repository.UserRepo, jwtMgr *auth.JWTManager, cfg *AuthConfig) *AuthService {
-	return &AuthService{repo: repo, jwtManager: jwtMgr, cfg: cfg}
+func NewAuthService(repo *repository.UserRepo, audit *repository.AuditRepo, otp *OTPService, jwtMgr *auth.JWTManager, cfg *AuthConfig) *AuthService {
+	hasher := cfg.PasswordHasher
+	if hasher == nil {
+		hasher, _ = auth.NewPasswordHasher(auth.PasswordAlgoBcrypt, auth.BcryptParams{Cost: cfg.BcryptCost}, auth.DefaultArgon2idParams)
+	}
+	return &AuthService{
+		repo:       repo,
+		audit:      audit,
+		otp:        otp,
+		jwtManager: jwtMgr,
+		cfg:        cfg,
+		hasher:     hasher,
+		idGuard:    NewLoginGuard(cfg.IdentifierGuard),
+		ipGuard:    NewLoginGuard(cfg.IPGuard),
+	}
+}
+
+// ClientInfo describes the device/network a login or refresh comes from; recorded on the session.
+type ClientInfo struct {
+	DeviceInfo string
+	IP         string
+	Latitude   *float64
+	Longitude  *float64
+}
+
+// maxDeviceInfoLen is the size of user_sessions.device_info.
+const maxDeviceInfoLen = 512
+
+// sanitized returns c fit for dbo.user_sessions: device info is cut to maxDeviceInfoLen and
+// out-of-range coordinates are dropped. Both are informational and never fail a login.
+func (c ClientInfo) sanitized() ClientInfo {
+	if r := []rune(c.DeviceInfo); len(r) > maxDeviceInfoLen {
+		c.DeviceInfo = string(r[:maxDeviceInfoLen])
+	}
+	if c.Latitude == nil || c.Longitude == nil ||
+		(geo.Point{Lat: *c.Latitude, Lon: *c.Longitude}).Validate() != nil {
+		c.Latitude, c.Longitude = nil, nil
+	}
+	return c
 }
 
 var ErrInvalidCredentials = errors.New("invalid credentials")
 var ErrRefreshTokenNotFound = errors.New("refresh token not found or revoked/expired")
+var ErrSessionRevoked = errors.New("session revoked")
+var ErrRefreshTokenReused = errors.New("refresh token reuse detected")
+var ErrSessionNotFound = errors.New("session not found")
+var ErrMobileNotVerified = errors.New("mobile number not verified")
 
 // NormalizeMobile removes non-digit characters except leading +.
 // Keep this small helper here for phase-1; consider moving to a shared util package later.
@@ -50,10 +123,14 @@ func NormalizeMobile(m string) string {
 }
 
 // Register creates a user with hashed password using user_credentials (not on users table).
+// The password must meet the same policy as ChangePassword (a *WeakPasswordError otherwise).
 func (s *AuthService) Register(ctx context.Context, mobile, password string) (string, error) {
 	if mobile == "" || password == "" {
 		return "", errors.New("mobile and password are required")
 	}
+	if err := s.validatePassword(password); err != nil {
+		return "", err
+	}
 	mobileNorm := NormalizeMobile(mobile)
 
 	// check existing credential (by normalized mobile)
@@ -65,7 +142,7 @@ func (s *AuthService) Register(ctx context.Context, mobile, password string) (st
 		return "", errors.New("user already exists")
 	}
 
-	phash, err := auth.HashPassword(password, s.cfg.BcryptCost)
+	phash, err := s.hasher.Hash(password)
 	if err != nil {
 		return "", err
 	}
@@ -78,44 +155,206 @@ func (s *AuthService) Register(ctx context.Context, mobile, password string) (st
 	return userID, nil
 }
 
-// Login verifies credentials and issues tokens.
-func (s *AuthService) Login(ctx context.Context, mobile, password, deviceInfo string) (accessToken string, accessExp time.Time, refreshRaw string, refreshExpiry time.Time, err error) {
-	if mobile == "" || password == "" {
+// Login verifies credentials and issues tokens. identifier may be a mobile number, a verified
+// email or a username.
+// Failures are throttled per account (per identifier when none matches) and per client IP; while
+// throttled a *LockedError is returned.
+// Unknown identifiers and wrong passwords both yield ErrInvalidCredentials.
+// Accounts with TOTP enabled get a *MFARequiredError carrying the challenge token for LoginMFA;
+// accounts pending deletion get a *DeletionPendingError (see RestoreAccount).
+func (s *AuthService) Login(ctx context.Context, identifier, password string, client ClientInfo) (accessToken string, accessExp time.Time, refreshRaw string, refreshExpiry time.Time, err error) {
+	if identifier == "" || password == "" {
 		err = ErrInvalidCredentials
 		return
 	}
-	mobileNorm := NormalizeMobile(mobile)
-
-	userID, pwHash, err := s.repo.GetCredentialByIdentifier(ctx, mobileNorm)
+	idType, idValue := classifyIdentifier(identifier)
+	userID, pwHash, err := s.resolvePasswordLogin(ctx, idType, idValue)
 	if err != nil {
 		return "", time.Time{}, "", time.Time{}, err
 	}
-	if userID == "" {
+	idKey := loginGuardKey(idType, idValue, userID)
+	if err := s.checkLoginGuards(idKey, client.IP); err != nil {
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	if userID == "" || pwHash == "" {
+		// burn the same time as a real comparison so response timing doesn't reveal the account
+		_, _, _ = s.hasher.Verify(s.dummyPasswordHash(), password)
+		s.recordLoginFailure(idKey, client.IP)
 		return "", time.Time{}, "", time.Time{}, ErrInvalidCredentials
 	}
 
-	if err := auth.ComparePassword(pwHash, password); err != nil {
+	ok, needsRehash, err := s.hasher.Verify(pwHash, password)
+	if err != nil && !errors.Is(err, auth.ErrUnknownPasswordHash) {
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	if !ok {
+		s.recordLoginFailure(idKey, client.IP)
 		return "", time.Time{}, "", time.Time{}, ErrInvalidCredentials
 	}
+	s.idGuard.Reset(idKey)
+	if needsRehash {
+		s.upgradePasswordHash(ctx, userID, pwHash, password)
+	}
+	if err := s.repo.TouchCredentialLastUsed(ctx, userID, "password"); err != nil {
+		return "", time.Time{}, "", time.Time{}, err
+	}
 
-	accessToken, accessExp, err = s.jwtManager.Generate(userID, nil)
-	if err != nil {
+	if err := s.checkMobileVerified(ctx, userID); err != nil {
 		return "", time.Time{}, "", time.Time{}, err
 	}
+	if err := s.requireSecondFactor(ctx, userID); err != nil {
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	return s.completeLogin(ctx, userID, client)
+}
 
+// checkMobileVerified enforces RequireMobileVerification for a user who just authenticated.
+func (s *AuthService) checkMobileVerified(ctx context.Context, userID string) error {
+	if !s.cfg.RequireMobileVerification {
+		return nil
+	}
+	u, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return ErrInvalidCredentials
+	}
+	if !u.IsMobileVerified {
+		return ErrMobileNotVerified
+	}
+	return nil
+}
+
+// issueTokens starts a session for an authenticated user and returns its first token pair.
+func (s *AuthService) issueTokens(ctx context.Context, userID string, client ClientInfo) (accessToken string, accessExp time.Time, refreshRaw string, refreshExpiry time.Time, err error) {
 	raw, hash, err := auth.GenerateRefreshToken(s.cfg.RefreshTokenBytes)
 	if err != nil {
 		return "", time.Time{}, "", time.Time{}, err
 	}
 	expiry := auth.RefreshTokenExpiry(s.cfg.RefreshTTL)
-	if _, err := s.repo.SaveRefreshToken(ctx, userID, hash, &deviceInfo, expiry); err != nil {
+	client = client.sanitized()
+	// the session id doubles as refresh token family id and is embedded in access tokens
+	sessionID, err := s.repo.StartSession(ctx, userID, hash, expiry, repository.SessionMeta{
+		DeviceInfo: optionalString(client.DeviceInfo),
+		IPAddress:  optionalString(client.IP),
+		Latitude:   client.Latitude,
+		Longitude:  client.Longitude,
+	})
+	if err != nil {
+		return "", time.Time{}, "", time.Time{}, err
+	}
+
+	roles, err := s.rolesFor(ctx, userID)
+	if err != nil {
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	accessToken, accessExp, err = s.jwtManager.Generate(userID, sessionID, roles)
+	if err != nil {
 		return "", time.Time{}, "", time.Time{}, err
 	}
 	return accessToken, accessExp, raw, expiry, nil
 }
 
+// Login identifier kinds accepted by classifyIdentifier.
+const (
+	identifierMobile   = "mobile"
+	identifierEmail    = "email"
+	identifierUsername = "username"
+)
+
+// classifyIdentifier decides whether a login identifier is an email, mobile number or
+// username, and returns it normalized.
+func classifyIdentifier(identifier string) (string, string) {
+	identifier = strings.TrimSpace(identifier)
+	if strings.Contains(identifier, "@") {
+		return identifierEmail, NormalizeEmail(identifier)
+	}
+	if looksLikeMobile(identifier) {
+		return identifierMobile, NormalizeMobile(identifier)
+	}
+	return identifierUsername, identifier
+}
+
+// looksLikeMobile: only digits and phone punctuation, with at least 7 digits.
+func looksLikeMobile(v string) bool {
+	digits := 0
+	for i, r := range v {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '+' && i == 0, r == ' ', r == '-', r == '(', r == ')':
+		default:
+			return false
+		}
+	}
+	return digits >= 7
+}
+
+// resolvePasswordLogin returns the user id and password hash for a classified identifier.
+func (s *AuthService) resolvePasswordLogin(ctx context.Context, idType, idValue string) (string, string, error) {
+	if idType == identifierMobile {
+		return s.repo.GetCredentialByIdentifier(ctx, idValue)
+	}
+	var userID string
+	var err error
+	if idType == identifierEmail {
+		userID, err = s.repo.GetUserIDByCredential(ctx, repository.CredentialEmail, idValue)
+	} else {
+		userID, err = s.repo.GetUserIDByUsername(ctx, idValue)
+	}
+	if err != nil || userID == "" {
+		return "", "", err
+	}
+	pwHash, err := s.repo.GetPasswordHashByUserID(ctx, userID)
+	if err != nil {
+		return "", "", err
+	}
+	return userID, pwHash, nil
+}
+
+// loginGuardKey is the idGuard key of a login attempt: the resolved account, so its mobile,
+// email and username share one failure budget, or the identifier itself when no account matched.
+func loginGuardKey(idType, idValue, userID string) string {
+	if userID != "" {
+		return "user:" + userID
+	}
+	return idType + ":" + idValue
+}
+
+func (s *AuthService) checkLoginGuards(idKey, ip string) error {
+	if err := s.idGuard.Check(idKey); err != nil {
+		return err
+	}
+	return s.ipGuard.Check(ip)
+}
+
+func (s *AuthService) recordLoginFailure(idKey, ip string) {
+	s.idGuard.Fail(idKey)
+	s.ipGuard.Fail(ip)
+}
+
+// dummyPasswordHash returns a hash of a fixed string with the current hasher settings.
+func (s *AuthService) dummyPasswordHash() string {
+	s.dummyOnce.Do(func() {
+		s.dummyHash, _ = s.hasher.Hash("gatherup-timing-equalizer")
+	})
+	return s.dummyHash
+}
+
+// upgradePasswordHash rehashes a verified password with current settings. Best-effort:
+// the login already succeeded, and the swap only applies if the stored hash is unchanged.
+func (s *AuthService) upgradePasswordHash(ctx context.Context, userID, oldHash, password string) {
+	newHash, err := s.hasher.Hash(password)
+	if err != nil {
+		return
+	}
+	_ = s.repo.ReplacePasswordHash(ctx, userID, oldHash, newHash)
+}
+
 // Refresh validates provided refresh token, rotates it and returns new tokens.
-func (s *AuthService) Refresh(ctx context.Context, raw string) (newAccess string, accessExp time.Time, newRaw string, newExpiry time.Time, err error) {
+// ip (optional) updates the session's last known address.
+func (s *AuthService) Refresh(ctx context.Context, raw, ip string) (newAccess string, accessExp time.Time, newRaw string, newExpiry time.Time, err error) {
 	if raw == "" {
 		return "", time.Time{}, "", time.Time{}, ErrRefreshTokenNotFound
 	}
@@ -124,13 +363,24 @@ func (s *AuthService) Refresh(ctx context.Context, raw string) (newAccess string
 	if err != nil {
 		return "", time.Time{}, "", time.Time{}, err
 	}
-	if row == nil || row.Revoked {
+	if row == nil {
+		return "", time.Time{}, "", time.Time{}, ErrRefreshTokenNotFound
+	}
+	if row.Revoked {
+		// a rotated token coming back means two parties hold the family: kill it
+		if row.Rotated {
+			return "", time.Time{}, "", time.Time{}, s.revokeReusedFamily(ctx, row)
+		}
 		return "", time.Time{}, "", time.Time{}, ErrRefreshTokenNotFound
 	}
 	if time.Now().UTC().After(row.ExpiresAt) {
 		return "", time.Time{}, "", time.Time{}, ErrRefreshTokenNotFound
 	}
-	newAccess, accessExp, err = s.jwtManager.Generate(row.UserID, nil)
+	roles, err := s.rolesFor(ctx, row.UserID)
+	if err != nil {
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	newAccess, accessExp, err = s.jwtManager.Generate(row.UserID, row.SessionID, roles)
 	if err != nil {
 		return "", time.Time{}, "", time.Time{}, err
 	}
@@ -140,7 +390,142 @@ func (s *AuthService) Refresh(ctx context.Context, raw string) (newAccess string
 	}
 	newExpiry = auth.RefreshTokenExpiry(s.cfg.RefreshTTL)
 	if _, err := s.repo.RotateRefreshToken(ctx, row.ID, newHash, newExpiry); err != nil {
+		if errors.Is(err, repository.ErrRefreshTokenRevoked) {
+			return "", time.Time{}, "", time.Time{}, s.revokeReusedFamily(ctx, row)
+		}
 		return "", time.Time{}, "", time.Time{}, err
 	}
+	// best-effort: the new token is already committed and must reach the client, otherwise
+	// its retry with the rotated token would look like reuse. Failures are logged by the repo.
+	_ = s.repo.TouchSession(ctx, row.SessionID, optionalString(ip), newExpiry)
 	return newAccess, accessExp, newRaw, newExpiry, nil
 }
+
+// revokeReusedFamily revokes every token of the row's family and records a security event.
+// It returns ErrRefreshTokenReused unless revocation itself failed.
+func (s *AuthService) revokeReusedFamily(ctx context.Context, row *repository.RefreshTokenRow) error {
+	n, err := s.repo.RevokeSession(ctx, row.SessionID)
+	if err != nil {
+		return err
+	}
+	payload, _ := json.Marshal(map[string]interface{}{
+		"user_id":  row.UserID,
+		"token_id": row.ID,
+		"revoked":  n,
+	})
+	p := string(payload)
+	// best-effort: the family is already revoked, a failed audit write is logged by the repo
+	_ = s.audit.Insert(ctx, &models.AuditLog{
+		EntityType: "refresh_token_family",
+		EntityID:   row.SessionID,
+		Action:     "refresh_token_reuse",
+		Payload:    &p,
+	})
+	return ErrRefreshTokenReused
+}
+
+// Logout revokes the session of the presented refresh token, which also invalidates
+// access tokens issued for that session. Unknown or already revoked tokens are a no-op.
+func (s *AuthService) Logout(ctx context.Context, raw string) error {
+	if raw == "" {
+		return ErrRefreshTokenNotFound
+	}
+	row, err := s.repo.GetRefreshTokenRow(ctx, auth.HashRefreshToken(raw))
+	if err != nil {
+		return err
+	}
+	if row == nil || row.Revoked {
+		return nil
+	}
+	_, err = s.repo.RevokeSession(ctx, row.SessionID)
+	return err
+}
+
+// LogoutAll revokes every refresh token (and therefore every session) of the user, along
+// with the API keys of the user and their bots.
+func (s *AuthService) LogoutAll(ctx context.Context, userID string) (int64, error) {
+	return s.repo.RevokeAllAccess(ctx, userID)
+}
+
+// VerifyAccessToken validates an access token and checks that its session was not revoked.
+func (s *AuthService) VerifyAccessToken(ctx context.Context, token string) (*auth.Claims, error) {
+	claims, err := s.jwtManager.Verify(token)
+	if err != nil {
+		return nil, err
+	}
+	if actorID := claims.ImpersonatorID(); actorID != "" {
+		// no session behind impersonation tokens; they live while the admin stays an admin
+		if err := s.checkImpersonator(ctx, actorID); err != nil {
+			return nil, err
+		}
+		return claims, nil
+	}
+	// tokens issued before sessions existed carry no sid; they expire on their own
+	if claims.SessionID == "" {
+		return claims, nil
+	}
+	active, err := s.repo.IsSessionActive(ctx, claims.SessionID)
+	if err != nil {
+		return nil, err
+	}
+	if !active {
+		return nil, ErrSessionRevoked
+	}
+	return claims, nil
+}
+
+// ListSessions returns the user's active sessions ("where am I logged in").
+func (s *AuthService) ListSessions(ctx context.Context, userID string) ([]models.UserSession, error) {
+	return s.repo.ListActiveSessions(ctx, userID)
+}
+
+// RevokeSession ends one of the user's sessions remotely (refresh and access tokens stop working).
+func (s *AuthService) RevokeSession(ctx context.Context, userID, sessionID string) error {
+	ok, err := s.repo.RevokeUserSession(ctx, userID, sessionID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// RequestMobileVerification sends a verification code to a registered mobile number.
+// Unknown or already verified numbers succeed silently so the endpoint can't enumerate accounts.
+func (s *AuthService) RequestMobileVerification(ctx context.Context, mobile string) error {
+	mobileNorm := NormalizeMobile(mobile)
+	userID, _, err := s.repo.GetCredentialByIdentifier(ctx, mobileNorm)
+	if err != nil || userID == "" {
+		return err
+	}
+	u, err := s.repo.GetByID(ctx, userID)
+	if err != nil || u == nil || u.IsMobileVerified {
+		return err
+	}
+	return s.otp.SendQuietly(ctx, OTPPurposeVerifyMobile, OTPChannelSMS, mobileNorm, &userID)
+}
+
+// VerifyMobile checks the code sent by RequestMobileVerification and marks the number verified.
+func (s *AuthService) VerifyMobile(ctx context.Context, mobile, code string) error {
+	mobileNorm := NormalizeMobile(mobile)
+	if err := s.otp.Verify(ctx, OTPPurposeVerifyMobile, mobileNorm, code); err != nil {
+		return err
+	}
+	userID, _, err := s.repo.GetCredentialByIdentifier(ctx, mobileNorm)
+	if err != nil {
+		return err
+	}
+	if userID == "" {
+		return ErrOTPInvalid
+	}
+	return s.repo.MarkMobileVerified(ctx, userID)
+}
+
+// optionalString maps "" to nil for nullable columns.
+func optionalString(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
@@ -0,0 +1,125 @@
+/* Place: backend/go/service/block_service.go */
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"gatherup/models"
+	"gatherup/repository"
+
+	"github.com/google/uuid"
+)
+
+// Block limits.
+const (
+	maxBlockReasonLen = 500
+	defaultBlockLimit = 50
+	maxBlockLimit     = 100
+)
+
+var ErrBlockSelf = errors.New("you cannot block yourself")
+var ErrBlockReason = errors.New("reason must be at most 500 characters")
+var ErrNotBlocked = errors.New("user is not blocked")
+
+// BlockService manages the caller's block list and is the policy every service consults
+// before one user reaches another (see CheckInteraction). Repository queries that list
+// users apply the same rule in SQL.
+type BlockService struct {
+	blocks *repository.BlockRepo
+	users  *repository.UserRepo
+}
+
+func NewBlockService(blocks *repository.BlockRepo, users *repository.UserRepo) *BlockService {
+	return &BlockService{blocks: blocks, users: users}
+}
+
+// BlockPage is one page of the block list; NextCursor is empty on the last page.
+type BlockPage struct {
+	Users      []models.BlockedUser `json:"users"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+// CheckInteraction returns ErrUserNotFound when actorID and targetID blocked each other in
+// either direction, so messaging, posts, profiles, invites and mentions fail the same way an
+// unknown user does and never reveal the block.
+func (s *BlockService) CheckInteraction(ctx context.Context, actorID, targetID string) error {
+	if actorID == targetID {
+		return nil
+	}
+	blocked, err := s.blocks.IsBlocked(ctx, actorID, targetID)
+	if err != nil {
+		return err
+	}
+	if blocked {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// Block blocks targetID for userID and ends any contact or request between them.
+// Blocking someone already blocked succeeds.
+func (s *BlockService) Block(ctx context.Context, userID, targetID, reason string) error {
+	if userID == targetID {
+		return ErrBlockSelf
+	}
+	reason = strings.TrimSpace(reason)
+	if len([]rune(reason)) > maxBlockReasonLen {
+		return ErrBlockReason
+	}
+	if _, err := uuid.Parse(targetID); err != nil {
+		return ErrUserNotFound
+	}
+	target, err := s.users.GetByID(ctx, targetID)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return ErrUserNotFound
+	}
+	_, err = s.blocks.Block(ctx, userID, targetID, optionalString(reason))
+	return err
+}
+
+// Unblock lifts userID's block of targetID.
+func (s *BlockService) Unblock(ctx context.Context, userID, targetID string) error {
+	if _, err := uuid.Parse(targetID); err != nil {
+		return ErrNotBlocked
+	}
+	ok, err := s.blocks.Unblock(ctx, userID, targetID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotBlocked
+	}
+	return nil
+}
+
+// List returns the users userID blocked, newest first.
+func (s *BlockService) List(ctx context.Context, userID, cursor string, limit int) (*BlockPage, error) {
+	if limit <= 0 {
+		limit = defaultBlockLimit
+	}
+	if limit > maxBlockLimit {
+		limit = maxBlockLimit
+	}
+	afterID, err := decodeRowCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+	items, err := s.blocks.List(ctx, userID, afterID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &BlockPage{Users: []models.BlockedUser{}}
+	for i, it := range items {
+		if i == limit {
+			page.NextCursor = encodeRowCursor(items[i-1].RowID)
+			break
+		}
+		page.Users = append(page.Users, it.BlockedUser)
+	}
+	return page, nil
+}
@@ -0,0 +1,274 @@
+/* Place: backend/go/service/contact_service.go */
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"strconv"
+
+	"gatherup/models"
+	"gatherup/repository"
+
+	"github.com/google/uuid"
+)
+
+// Contact limits.
+const (
+	// maxPendingContactRequests caps unanswered outgoing requests per user.
+	maxPendingContactRequests = 100
+	defaultContactLimit       = 50
+	maxContactLimit           = 100
+)
+
+var ErrContactSelf = errors.New("you cannot add yourself as a contact")
+var ErrAlreadyContacts = errors.New("already contacts")
+var ErrContactRequestExists = errors.New("contact request already sent")
+var ErrContactRequestNotFound = errors.New("contact request not found")
+var ErrNotContacts = errors.New("not a contact")
+var ErrTooManyContactRequests = errors.New("too many pending contact requests")
+var ErrContactStatus = errors.New("status must be accepted, incoming or outgoing")
+
+// ContactService runs the contact request lifecycle. Accepted contacts are what the
+// 'contacts' visibility of posts and tournaments, profiles and search rely on.
+type ContactService struct {
+	contacts *repository.ContactRepo
+	users    *repository.UserRepo
+	blocks   *BlockService
+}
+
+func NewContactService(contacts *repository.ContactRepo, users *repository.UserRepo, blocks *BlockService) *ContactService {
+	return &ContactService{contacts: contacts, users: users, blocks: blocks}
+}
+
+// ContactPage is one page of a contact list; NextCursor is empty on the last page.
+type ContactPage struct {
+	Contacts   []models.ContactEntry `json:"contacts"`
+	NextCursor string                `json:"next_cursor,omitempty"`
+}
+
+// SendRequest asks targetID to become userID's contact. If targetID already asked userID,
+// that request is accepted instead. Returns the resulting status (pending or accepted).
+func (s *ContactService) SendRequest(ctx context.Context, userID, targetID string) (string, error) {
+	me, err := s.reachable(ctx, userID, targetID)
+	if err != nil {
+		return "", err
+	}
+	pair, err := s.contacts.GetPair(ctx, userID, targetID)
+	if err != nil {
+		return "", err
+	}
+	if pair != nil {
+		switch {
+		case pair.Status == models.ContactAccepted:
+			return "", ErrAlreadyContacts
+		case pair.UserID == userID:
+			return "", ErrContactRequestExists
+		}
+		if err := s.accept(ctx, me, targetID); err != nil {
+			return "", err
+		}
+		return models.ContactAccepted, nil
+	}
+
+	pending, err := s.contacts.CountPendingOutgoing(ctx, userID)
+	if err != nil {
+		return "", err
+	}
+	if pending >= maxPendingContactRequests {
+		return "", ErrTooManyContactRequests
+	}
+	n := contactNotification(me, targetID, models.NotificationContactRequest, " wants to add you as a contact")
+	if err := s.contacts.CreateRequest(ctx, userID, targetID, n); err != nil {
+		if errors.Is(err, repository.ErrDuplicate) {
+			return "", ErrContactRequestExists
+		}
+		return "", err
+	}
+	return models.ContactPending, nil
+}
+
+// Accept accepts requesterID's pending request to userID and notifies the requester.
+func (s *ContactService) Accept(ctx context.Context, userID, requesterID string) error {
+	me, err := s.reachable(ctx, userID, requesterID)
+	if err != nil {
+		return err
+	}
+	return s.accept(ctx, me, requesterID)
+}
+
+func (s *ContactService) accept(ctx context.Context, me *models.User, requesterID string) error {
+	n := contactNotification(me, requesterID, models.NotificationContactAccepted, " accepted your contact request")
+	ok, err := s.contacts.Accept(ctx, requesterID, me.ID, n)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrContactRequestNotFound
+	}
+	return nil
+}
+
+// Decline rejects requesterID's pending request to userID. The requester is not notified.
+func (s *ContactService) Decline(ctx context.Context, userID, requesterID string) error {
+	if _, err := uuid.Parse(requesterID); err != nil {
+		return ErrContactRequestNotFound
+	}
+	ok, err := s.contacts.Decline(ctx, requesterID, userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrContactRequestNotFound
+	}
+	return nil
+}
+
+// Cancel withdraws userID's pending request to targetID.
+func (s *ContactService) Cancel(ctx context.Context, userID, targetID string) error {
+	if _, err := uuid.Parse(targetID); err != nil {
+		return ErrContactRequestNotFound
+	}
+	ok, err := s.contacts.Cancel(ctx, userID, targetID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrContactRequestNotFound
+	}
+	return nil
+}
+
+// Unfriend ends the contact between userID and otherID.
+func (s *ContactService) Unfriend(ctx context.Context, userID, otherID string) error {
+	if _, err := uuid.Parse(otherID); err != nil {
+		return ErrNotContacts
+	}
+	ok, err := s.contacts.Remove(ctx, userID, otherID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrNotContacts
+	}
+	return nil
+}
+
+// List returns userID's contacts (status accepted, the default) or pending requests
+// (incoming or outgoing), newest first.
+func (s *ContactService) List(ctx context.Context, userID, status, cursor string, limit int) (*ContactPage, error) {
+	switch status {
+	case "":
+		status = models.ContactAccepted
+	case models.ContactAccepted, models.ContactIncoming, models.ContactOutgoing:
+	default:
+		return nil, ErrContactStatus
+	}
+	if limit <= 0 {
+		limit = defaultContactLimit
+	}
+	if limit > maxContactLimit {
+		limit = maxContactLimit
+	}
+	afterID, err := decodeRowCursor(cursor)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.contacts.List(ctx, userID, status, afterID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+	page := &ContactPage{Contacts: []models.ContactEntry{}}
+	for i, it := range items {
+		if i == limit {
+			page.NextCursor = encodeRowCursor(items[i-1].RowID)
+			break
+		}
+		page.Contacts = append(page.Contacts, it.ContactEntry)
+	}
+	return page, nil
+}
+
+// Mutual returns up to limit contacts that userID and otherID have in common.
+func (s *ContactService) Mutual(ctx context.Context, userID, otherID string, limit int) ([]models.UserSummary, error) {
+	if _, err := s.reachable(ctx, userID, otherID); err != nil {
+		return nil, err
+	}
+	if limit <= 0 {
+		limit = defaultContactLimit
+	}
+	if limit > maxContactLimit {
+		limit = maxContactLimit
+	}
+	return s.contacts.ListMutual(ctx, userID, otherID, limit)
+}
+
+// reachable loads userID and checks that otherID is another active, non-bot user with no
+// block between the two. Anything else is ErrUserNotFound, so a block is never revealed.
+func (s *ContactService) reachable(ctx context.Context, userID, otherID string) (*models.User, error) {
+	if userID == otherID {
+		return nil, ErrContactSelf
+	}
+	if _, err := uuid.Parse(otherID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	other, err := s.users.GetByID(ctx, otherID)
+	if err != nil {
+		return nil, err
+	}
+	if other == nil || !other.IsActive || other.IsBot {
+		return nil, ErrUserNotFound
+	}
+	if err := s.blocks.CheckInteraction(ctx, userID, otherID); err != nil {
+		return nil, err
+	}
+	me, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if me == nil {
+		return nil, ErrUserNotFound
+	}
+	return me, nil
+}
+
+// encodeRowCursor and decodeRowCursor page lists ordered by descending row id; "" is the first page.
+func encodeRowCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeRowCursor(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(b), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
+
+// contactNotification builds the notification sent to recipientID about actor.
+func contactNotification(actor *models.User, recipientID, kind, action string) *models.Notification {
+	name := "Someone"
+	switch {
+	case actor.DisplayName != nil && *actor.DisplayName != "":
+		name = *actor.DisplayName
+	case actor.Username != nil && *actor.Username != "":
+		name = *actor.Username
+	}
+	refType := "user"
+	return &models.Notification{
+		UserID:        recipientID,
+		ActorID:       &actor.ID,
+		Kind:          kind,
+		ReferenceType: &refType,
+		ReferenceID:   &actor.ID,
+		Title:         optionalString(name + action),
+	}
+}
@@ -0,0 +1,112 @@
+/* Place: backend/go/service/credential_service.go */
+package service
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"strings"
+
+	"gatherup/models"
+	"gatherup/repository"
+)
+
+var ErrInvalidEmail = errors.New("invalid email address")
+var ErrEmailAlreadyLinked = errors.New("an email is already linked; remove it first")
+var ErrCredentialTaken = errors.New("identifier is already used by another account")
+var ErrCredentialNotFound = errors.New("credential not found")
+var ErrPasswordCredential = errors.New("the password credential cannot be removed; change the password instead")
+var ErrCredentialManaged = errors.New("two-factor credentials are managed through the 2fa endpoints")
+
+// NormalizeEmail trims and lower-cases an email address.
+func NormalizeEmail(e string) string {
+	return strings.ToLower(strings.TrimSpace(e))
+}
+
+// ListCredentials returns the user's linked credentials without secrets.
+// Pending TOTP enrollments and individual recovery codes are not listed.
+func (s *AuthService) ListCredentials(ctx context.Context, userID string) ([]models.Credential, error) {
+	creds, err := s.repo.ListCredentials(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	out := creds[:0]
+	for _, c := range creds {
+		if c.Type == repository.CredentialTOTPPending || c.Type == repository.CredentialRecoveryCode {
+			continue
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// RequestEmailLink sends a verification code to email; the address is linked only after VerifyEmailLink.
+func (s *AuthService) RequestEmailLink(ctx context.Context, userID, email string) error {
+	email = NormalizeEmail(email)
+	if addr, err := mail.ParseAddress(email); err != nil || addr.Address != email {
+		return ErrInvalidEmail
+	}
+	creds, err := s.repo.ListCredentials(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, c := range creds {
+		if c.Type == repository.CredentialEmail {
+			return ErrEmailAlreadyLinked
+		}
+	}
+	owner, err := s.repo.GetUserIDByCredential(ctx, repository.CredentialEmail, email)
+	if err != nil {
+		return err
+	}
+	if owner != "" {
+		return ErrCredentialTaken
+	}
+	return s.otp.Send(ctx, OTPPurposeVerifyEmail, OTPChannelEmail, email, &userID)
+}
+
+// VerifyEmailLink checks the code from RequestEmailLink and links the email as a login identifier.
+func (s *AuthService) VerifyEmailLink(ctx context.Context, userID, email, code string) error {
+	email = NormalizeEmail(email)
+	if err := s.otp.VerifyForUser(ctx, OTPPurposeVerifyEmail, email, code, userID); err != nil {
+		return err
+	}
+	err := s.repo.AddVerifiedEmail(ctx, userID, email)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return ErrCredentialTaken
+	}
+	return err
+}
+
+// RemoveCredential unlinks a credential. The password can't be removed, so the account always
+// keeps a way to sign in.
+func (s *AuthService) RemoveCredential(ctx context.Context, userID string, credentialID int64) error {
+	creds, err := s.repo.ListCredentials(ctx, userID)
+	if err != nil {
+		return err
+	}
+	var target *models.Credential
+	for i := range creds {
+		if creds[i].ID == credentialID {
+			target = &creds[i]
+			break
+		}
+	}
+	if target == nil {
+		return ErrCredentialNotFound
+	}
+	switch target.Type {
+	case repository.CredentialPassword:
+		return ErrPasswordCredential
+	case repository.CredentialTOTP, repository.CredentialTOTPPending, repository.CredentialRecoveryCode:
+		return ErrCredentialManaged
+	}
+	ok, err := s.repo.DeleteCredential(ctx, userID, credentialID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrCredentialNotFound
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+/* Place: backend/go/service/deletion_service.go */
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"gatherup/auth"
+	"gatherup/models"
+)
+
+var ErrDeletionAlreadyScheduled = errors.New("account deletion is already scheduled")
+var ErrDeletionNotPending = errors.New("no pending account deletion to cancel")
+
+// DeletionPendingError is returned by the login methods for an account in its deletion grace
+// period. RestoreToken can be passed to RestoreAccount before ExpiresAt to cancel the deletion.
+type DeletionPendingError struct {
+	ScheduledAt  time.Time
+	RestoreToken string
+	ExpiresAt    time.Time
+}
+
+func (e *DeletionPendingError) Error() string { return "account is scheduled for deletion" }
+
+// RequestAccountDeletion re-checks the password, then schedules the account for deletion after
+// the grace period. The account is hidden and signed out everywhere immediately.
+func (s *AuthService) RequestAccountDeletion(ctx context.Context, userID, password string) (time.Time, error) {
+	pwHash, err := s.repo.GetPasswordHashByUserID(ctx, userID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if pwHash == "" {
+		return time.Time{}, ErrInvalidCredentials
+	}
+	if ok, _, _ := s.hasher.Verify(pwHash, password); !ok {
+		return time.Time{}, ErrInvalidCredentials
+	}
+	at := time.Now().UTC().Add(s.cfg.DeletionGracePeriod)
+	ok, err := s.repo.ScheduleDeletion(ctx, userID, at)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if !ok {
+		return time.Time{}, ErrDeletionAlreadyScheduled
+	}
+	s.auditDeletion(ctx, userID, "account_deletion_requested", &at)
+	return at, nil
+}
+
+// RestoreAccount cancels a pending deletion using the token from a *DeletionPendingError and
+// completes the interrupted login.
+func (s *AuthService) RestoreAccount(ctx context.Context, restoreToken string, client ClientInfo) (accessToken string, accessExp time.Time, refreshRaw string, refreshExpiry time.Time, err error) {
+	claims, err := s.jwtManager.VerifyPurpose(restoreToken, auth.PurposeRestore)
+	if err != nil {
+		return "", time.Time{}, "", time.Time{}, ErrDeletionNotPending
+	}
+	ok, err := s.repo.CancelDeletion(ctx, claims.UserID)
+	if err != nil {
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	if !ok {
+		return "", time.Time{}, "", time.Time{}, ErrDeletionNotPending
+	}
+	s.auditDeletion(ctx, claims.UserID, "account_deletion_cancelled", nil)
+	return s.issueTokens(ctx, claims.UserID, client)
+}
+
+// completeLogin issues tokens for a fully authenticated user, unless the account is pending
+// deletion, in which case the caller gets the chance to restore it instead.
+func (s *AuthService) completeLogin(ctx context.Context, userID string, client ClientInfo) (accessToken string, accessExp time.Time, refreshRaw string, refreshExpiry time.Time, err error) {
+	scheduled, err := s.repo.GetDeletionSchedule(ctx, userID)
+	if err != nil {
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	if scheduled != nil {
+		token, exp, err := s.jwtManager.GeneratePurpose(userID, auth.PurposeRestore, s.cfg.MFAChallengeTTL)
+		if err != nil {
+			return "", time.Time{}, "", time.Time{}, err
+		}
+		return "", time.Time{}, "", time.Time{}, &DeletionPendingError{ScheduledAt: *scheduled, RestoreToken: token, ExpiresAt: exp}
+	}
+	return s.issueTokens(ctx, userID, client)
+}
+
+func (s *AuthService) auditDeletion(ctx context.Context, userID, action string, scheduledAt *time.Time) {
+	var p *string
+	if scheduledAt != nil {
+		payload, _ := json.Marshal(map[string]time.Time{"scheduled_at": *scheduledAt})
+		v := string(payload)
+		p = &v
+	}
+	// best-effort: failures are logged by the repo
+	_ = s.audit.Insert(ctx, &models.AuditLog{
+		EntityType:  "user",
+		EntityID:    userID,
+		Action:      action,
+		PerformedBy: &userID,
+		Payload:     p,
+	})
+}
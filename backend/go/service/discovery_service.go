@@ -0,0 +1,194 @@
+/* Place: backend/go/service/discovery_service.go */
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"gatherup/repository"
+)
+
+// DiscoveryConfig controls phone-book contact discovery.
+type DiscoveryConfig struct {
+	// Salt is prepended to normalized numbers before hashing; empty disables discovery.
+	Salt string
+	// MaxBatch caps hashes per request; MaxBatchesPerHour caps requests per user.
+	MaxBatch          int
+	MaxBatchesPerHour int
+}
+
+// DiscoveryService matches hashed address-book numbers against verified mobile numbers.
+// Clients send lowercase hex SHA-256(Salt + NormalizeMobile(number)); numbers never leave the
+// device in clear text.
+type DiscoveryService struct {
+	users   *repository.UserRepo
+	cfg     *DiscoveryConfig
+	limiter *discoveryLimiter
+}
+
+func NewDiscoveryService(users *repository.UserRepo, cfg *DiscoveryConfig) *DiscoveryService {
+	return &DiscoveryService{users: users, cfg: cfg, limiter: &discoveryLimiter{seen: map[string][]time.Time{}}}
+}
+
+var ErrDiscoveryDisabled = errors.New("contact discovery is not enabled")
+var ErrDiscoveryHash = errors.New("hashes must be hex-encoded SHA-256 values")
+var ErrDiscoveryRateLimited = errors.New("too many discovery requests")
+
+// DiscoveryBatchError is returned when a batch is empty or larger than Max.
+type DiscoveryBatchError struct {
+	Max int
+}
+
+func (e *DiscoveryBatchError) Error() string {
+	return fmt.Sprintf("send between 1 and %d hashes", e.Max)
+}
+
+// DiscoveryLimitError is returned while a user is over MaxBatchesPerHour; it wraps
+// ErrDiscoveryRateLimited.
+type DiscoveryLimitError struct {
+	RetryAfter time.Duration
+}
+
+func (e *DiscoveryLimitError) Error() string {
+	return fmt.Sprintf("too many discovery requests; retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *DiscoveryLimitError) Unwrap() error { return ErrDiscoveryRateLimited }
+
+// DiscoveryInfo tells clients how to hash numbers for Discover.
+type DiscoveryInfo struct {
+	Algorithm string `json:"algorithm"`
+	Salt      string `json:"salt"`
+	MaxBatch  int    `json:"max_batch"`
+}
+
+// DiscoveryMatch pairs a submitted hash with the user it belongs to.
+type DiscoveryMatch struct {
+	Hash   string `json:"hash"`
+	UserID string `json:"user_id"`
+}
+
+// MobileLookupHash returns the hex SHA-256 of salt followed by the normalized mobile, the
+// value clients compute for each address-book entry.
+func MobileLookupHash(salt, mobile string) string {
+	sum := mobileLookupSum(salt, NormalizeMobile(mobile))
+	return hex.EncodeToString(sum[:])
+}
+
+func mobileLookupSum(salt, normalized string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(salt + normalized))
+}
+
+// Info returns the hashing parameters for clients.
+func (s *DiscoveryService) Info() (*DiscoveryInfo, error) {
+	if s.cfg.Salt == "" {
+		return nil, ErrDiscoveryDisabled
+	}
+	return &DiscoveryInfo{Algorithm: "sha256", Salt: s.cfg.Salt, MaxBatch: s.cfg.MaxBatch}, nil
+}
+
+// Discover returns which of hashes belong to active users with a verified mobile, other than
+// userID and users blocked in either direction.
+func (s *DiscoveryService) Discover(ctx context.Context, userID string, hashes []string) ([]DiscoveryMatch, error) {
+	if s.cfg.Salt == "" {
+		return nil, ErrDiscoveryDisabled
+	}
+	if len(hashes) == 0 || len(hashes) > s.cfg.MaxBatch {
+		return nil, &DiscoveryBatchError{Max: s.cfg.MaxBatch}
+	}
+	seen := make(map[string]bool, len(hashes))
+	raw := make([][]byte, 0, len(hashes))
+	for _, h := range hashes {
+		h = strings.ToLower(strings.TrimSpace(h))
+		b, err := hex.DecodeString(h)
+		if err != nil || len(b) != sha256.Size {
+			return nil, ErrDiscoveryHash
+		}
+		if !seen[h] {
+			seen[h] = true
+			raw = append(raw, b)
+		}
+	}
+	if err := s.limiter.take(userID, s.cfg.MaxBatchesPerHour, time.Now()); err != nil {
+		return nil, err
+	}
+
+	found, err := s.users.MatchMobileLookupHashes(ctx, userID, raw)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]DiscoveryMatch, 0, len(found))
+	for _, m := range found {
+		out = append(out, DiscoveryMatch{Hash: hex.EncodeToString(m.Hash), UserID: m.UserID})
+	}
+	return out, nil
+}
+
+// TakeMobileLookup counts an exact-number lookup made outside Discover (user search) against
+// the same hourly per-user budget, so search can't be used to enumerate numbers past it.
+func (s *DiscoveryService) TakeMobileLookup(userID string) error {
+	return s.limiter.take(userID, s.cfg.MaxBatchesPerHour, time.Now())
+}
+
+// IndexMobileLookups computes mobile_lookup_hash for up to batch newly verified users and
+// returns how many it stored. Run by the worker.
+func (s *DiscoveryService) IndexMobileLookups(ctx context.Context, batch int) (int, error) {
+	if s.cfg.Salt == "" {
+		return 0, nil
+	}
+	pending, err := s.users.ListMobileLookupPending(ctx, batch)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, p := range pending {
+		sum := mobileLookupSum(s.cfg.Salt, p.Mobile)
+		if err := s.users.SetMobileLookupHash(ctx, p.UserID, p.Mobile, sum[:]); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+// discoveryLimiter counts discovery requests per user over the last hour in memory. State is
+// per process; behind several instances each enforces its own limit.
+type discoveryLimiter struct {
+	mu   sync.Mutex
+	seen map[string][]time.Time
+}
+
+func (l *discoveryLimiter) take(userID string, max int, now time.Time) error {
+	if max <= 0 {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	cutoff := now.Add(-time.Hour)
+	recent := l.seen[userID][:0]
+	for _, t := range l.seen[userID] {
+		if t.After(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= max {
+		l.seen[userID] = recent
+		return &DiscoveryLimitError{RetryAfter: recent[0].Add(time.Hour).Sub(now)}
+	}
+	l.seen[userID] = append(recent, now)
+	// forget users whose window is empty so the map doesn't grow without bound
+	if len(l.seen) > loginGuardSweepSize {
+		for id, ts := range l.seen {
+			if len(ts) == 0 || !ts[len(ts)-1].After(cutoff) {
+				delete(l.seen, id)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+/* Place: backend/go/service/export_service.go */
+package service
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"gatherup/models"
+	"gatherup/repository"
+	"gatherup/storage"
+)
+
+// ExportConfig controls data export retention.
+type ExportConfig struct {
+	// TTL is how long a finished export stays downloadable.
+	TTL time.Duration
+}
+
+// ExportService handles "download my data" requests. The archive itself is built by the
+// worker (BuildExport) and kept in storage until it expires.
+type ExportService struct {
+	exports *repository.ExportRepo
+	store   storage.Storage
+	cfg     *ExportConfig
+}
+
+func NewExportService(exports *repository.ExportRepo, store storage.Storage, cfg *ExportConfig) *ExportService {
+	return &ExportService{exports: exports, store: store, cfg: cfg}
+}
+
+var ErrExportNotFound = errors.New("export not found")
+var ErrExportNotReady = errors.New("export is not ready yet")
+var ErrExportExpired = errors.New("export has expired")
+
+// RequestExport queues a new export, or returns the one already in progress.
+func (s *ExportService) RequestExport(ctx context.Context, userID string) (*models.DataExport, error) {
+	active, err := s.exports.FindActive(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return active, nil
+	}
+	return s.exports.Create(ctx, userID)
+}
+
+// GetExport returns the user's export id.
+func (s *ExportService) GetExport(ctx context.Context, userID, id string) (*models.DataExport, error) {
+	e, err := s.exports.GetForUser(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if e == nil {
+		return nil, ErrExportNotFound
+	}
+	return e, nil
+}
+
+// OpenExport returns the archive of a completed, unexpired export. The caller closes the reader.
+func (s *ExportService) OpenExport(ctx context.Context, userID, id string) (*models.DataExport, io.ReadCloser, error) {
+	e, err := s.GetExport(ctx, userID, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if e.Status != models.ExportCompleted || e.StorageKey == nil {
+		return nil, nil, ErrExportNotReady
+	}
+	if e.ExpiresAt != nil && !time.Now().Before(*e.ExpiresAt) {
+		return nil, nil, ErrExportExpired
+	}
+	rc, err := s.store.Open(ctx, *e.StorageKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return nil, nil, ErrExportExpired
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	return e, rc, nil
+}
+
+type exportManifest struct {
+	ExportID    string    `json:"export_id"`
+	UserID      string    `json:"user_id"`
+	GeneratedAt time.Time `json:"generated_at"`
+	Files       []string  `json:"files"`
+}
+
+// BuildExport gathers every dataset of the export's owner into a zip with one JSON file per
+// dataset plus manifest.json, stores it and marks the export completed. The zip is streamed
+// into storage row by row, so memory use doesn't grow with the account. Errors leave the
+// export running so the job can be retried; FailExport records a permanent failure.
+func (s *ExportService) BuildExport(ctx context.Context, exportID string) error {
+	e, err := s.exports.GetByID(ctx, exportID)
+	if err != nil {
+		return err
+	}
+	if e == nil || e.Status == models.ExportCompleted || e.Status == models.ExportFailed {
+		// removed or already finished by an earlier attempt
+		return nil
+	}
+	if err := s.exports.MarkRunning(ctx, e.ID); err != nil {
+		return err
+	}
+
+	pr, pw := io.Pipe()
+	written := make(chan error, 1)
+	go func() {
+		err := s.writeArchive(ctx, e, pw)
+		_ = pw.CloseWithError(err)
+		written <- err
+	}()
+	key := fmt.Sprintf("exports/%s/%s.zip", e.UserID, e.ID)
+	size, err := s.store.Put(ctx, key, pr)
+	_ = pr.Close() // unblocks the writer if Put stopped reading early
+	werr := <-written
+	if err != nil {
+		return err
+	}
+	if werr != nil {
+		return werr
+	}
+	return s.exports.MarkCompleted(ctx, e.ID, key, size, time.Now().UTC().Add(s.cfg.TTL))
+}
+
+// writeArchive writes the zip of e's datasets and manifest to w.
+func (s *ExportService) writeArchive(ctx context.Context, e *models.DataExport, w io.Writer) error {
+	zw := zip.NewWriter(w)
+	manifest := exportManifest{ExportID: e.ID, UserID: e.UserID, GeneratedAt: time.Now().UTC()}
+	for _, ds := range repository.ExportDatasets {
+		name := ds.Name + ".json"
+		if err := s.writeZipDataset(ctx, zw, name, ds.Query, e.UserID); err != nil {
+			return fmt.Errorf("dataset %s: %w", ds.Name, err)
+		}
+		manifest.Files = append(manifest.Files, name)
+	}
+	if err := writeZipJSON(zw, "manifest.json", manifest); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// writeZipDataset writes the rows of query as a JSON array, one row at a time.
+func (s *ExportService) writeZipDataset(ctx context.Context, zw *zip.Writer, name, query, userID string) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, "["); err != nil {
+		return err
+	}
+	sep := "\n  "
+	err = s.exports.EachDatasetRow(ctx, query, userID, func(row map[string]interface{}) error {
+		b, err := json.MarshalIndent(row, "  ", "  ")
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, sep); err != nil {
+			return err
+		}
+		sep = ",\n  "
+		_, err = w.Write(b)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	end := "\n]\n"
+	if sep == "\n  " {
+		end = "]\n"
+	}
+	_, err = io.WriteString(w, end)
+	return err
+}
+
+// FailExport marks an export as permanently failed after its job ran out of attempts. The
+// underlying error stays in dbo.jobs; users only see a generic message.
+func (s *ExportService) FailExport(ctx context.Context, exportID string) error {
+	return s.exports.MarkFailed(ctx, exportID, "export could not be generated")
+}
+
+// PurgeExpired deletes the files of up to limit expired exports and returns how many were removed.
+func (s *ExportService) PurgeExpired(ctx context.Context, limit int) (int, error) {
+	expired, err := s.exports.ListExpired(ctx, time.Now().UTC(), limit)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, e := range expired {
+		if e.StorageKey != nil {
+			if err := s.store.Delete(ctx, *e.StorageKey); err != nil && !errors.Is(err, storage.ErrNotFound) {
+				return n, err
+			}
+		}
+		if err := s.exports.MarkDeleted(ctx, e.ID); err != nil {
+			return n, err
+		}
+		n++
+	}
+	return n, nil
+}
+
+func writeZipJSON(zw *zip.Writer, name string, v interface{}) error {
+	w, err := zw.Create(name)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
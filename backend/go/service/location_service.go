@@ -0,0 +1,152 @@
+/* Place: backend/go/service/location_service.go */
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"gatherup/geo"
+	"gatherup/models"
+	"gatherup/repository"
+)
+
+// Location precisions accepted by UpdateLocation and the decimals they keep.
+var locationPrecisions = map[string]int{
+	"exact":        6, // as stored by DECIMAL(9,6)
+	"street":       3, // ~110 m
+	"neighborhood": 2, // ~1.1 km
+	"city":         1, // ~11 km
+}
+
+// DefaultLocationPrecision is used when the client does not ask for one.
+const DefaultLocationPrecision = "neighborhood"
+
+// Nearby search limits.
+const (
+	defaultNearbyRadiusKm = 10
+	maxNearbyRadiusKm     = 100
+	defaultNearbyLimit    = 50
+	maxNearbyLimit        = 100
+	// nearbyCandidates caps the rows the bounding box prefilter may return (the closest ones).
+	nearbyCandidates = 1000
+	// nearbyMaxAge hides users whose location is older than this.
+	nearbyMaxAge = 30 * 24 * time.Hour
+)
+
+var ErrLocationPrecision = errors.New("precision must be one of exact, street, neighborhood, city")
+var ErrLocationNotSet = errors.New("set your location first")
+var ErrNearbyRadius = errors.New("radius_km must be between 0 and 100")
+var ErrUnknownGameType = errors.New("unknown game type")
+
+// UpdateLocation stores the caller's location rounded to precision ("" means
+// DefaultLocationPrecision). ifMatch works as in UpdateProfile.
+func (s *ProfileService) UpdateLocation(ctx context.Context, userID string, p geo.Point, precision string, ifMatch []byte) (*models.User, error) {
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	if precision == "" {
+		precision = DefaultLocationPrecision
+	}
+	decimals, ok := locationPrecisions[precision]
+	if !ok {
+		return nil, ErrLocationPrecision
+	}
+	rounded := geo.Round(p, decimals)
+	if err := s.setLocation(ctx, userID, &rounded, ifMatch); err != nil {
+		return nil, err
+	}
+	return s.GetProfile(ctx, userID)
+}
+
+// ClearLocation removes the caller's location; they stop appearing in nearby searches.
+func (s *ProfileService) ClearLocation(ctx context.Context, userID string, ifMatch []byte) error {
+	return s.setLocation(ctx, userID, nil, ifMatch)
+}
+
+func (s *ProfileService) setLocation(ctx context.Context, userID string, p *geo.Point, ifMatch []byte) error {
+	if ifMatch != nil {
+		u, err := s.GetProfile(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if !bytes.Equal(ifMatch, u.Rv) {
+			return ErrPreconditionFailed
+		}
+	}
+	ok, err := s.users.UpdateLocation(ctx, userID, p, ifMatch)
+	if errors.Is(err, repository.ErrStale) {
+		return ErrPreconditionFailed
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrUserNotFound
+	}
+	return nil
+}
+
+// FindNearby returns players within radiusKm (0 means the default) of the caller's stored
+// location, nearest first. gameType (a game_types code) limits results to players with a
+// public skill for it.
+func (s *ProfileService) FindNearby(ctx context.Context, viewerID string, radiusKm float64, gameType string, limit int) ([]models.NearbyUser, error) {
+	if radiusKm == 0 {
+		radiusKm = defaultNearbyRadiusKm
+	}
+	if !(radiusKm > 0 && radiusKm <= maxNearbyRadiusKm) {
+		return nil, ErrNearbyRadius
+	}
+	if limit <= 0 {
+		limit = defaultNearbyLimit
+	}
+	if limit > maxNearbyLimit {
+		limit = maxNearbyLimit
+	}
+	gameType = strings.ToLower(strings.TrimSpace(gameType))
+	if gameType != "" {
+		ok, err := s.users.GameTypeExists(ctx, gameType)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrUnknownGameType
+		}
+	}
+
+	me, err := s.GetProfile(ctx, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	if me.Latitude == nil || me.Longitude == nil {
+		return nil, ErrLocationNotSet
+	}
+	center := geo.Point{Lat: *me.Latitude, Lon: *me.Longitude}
+
+	candidates, err := s.users.ListUsersInBox(ctx, viewerID, center, geo.BoundingBox(center, radiusKm), gameType,
+		time.Now().UTC().Add(-nearbyMaxAge), nearbyCandidates)
+	if err != nil {
+		return nil, err
+	}
+	out := []models.NearbyUser{}
+	for _, c := range candidates {
+		d := geo.HaversineKm(center, c.Point)
+		if d > radiusKm {
+			continue
+		}
+		c.DistanceKm = d
+		out = append(out, c.NearbyUser)
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].DistanceKm < out[j].DistanceKm })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	for i := range out {
+		out[i].DistanceKm = math.Max(1, math.Ceil(out[i].DistanceKm))
+	}
+	return out, nil
+}
@@ -0,0 +1,121 @@
+/* Place: backend/go/service/login_guard.go */
+package service
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LoginGuardConfig controls progressive delays and lockout for failed logins.
+type LoginGuardConfig struct {
+	// FreeAttempts failures are allowed before delays kick in.
+	FreeAttempts int
+	// BaseDelay doubles with every failure past FreeAttempts, capped at MaxDelay.
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutThreshold failures lock the key for LockoutDuration.
+	LockoutThreshold int
+	LockoutDuration  time.Duration
+	// Window forgets failures after this long without a new one.
+	Window time.Duration
+}
+
+var ErrTooManyAttempts = errors.New("too many failed attempts")
+
+// LockedError is returned while a key is delayed or locked out; it wraps ErrTooManyAttempts.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many failed attempts; retry after %s", e.RetryAfter.Round(time.Second))
+}
+
+func (e *LockedError) Unwrap() error { return ErrTooManyAttempts }
+
+type loginFailures struct {
+	count        int
+	lastFailure  time.Time
+	blockedUntil time.Time
+}
+
+// LoginGuard counts failed logins per key (credential identifier or client IP) in memory.
+// State is per process; behind several instances each enforces its own limits.
+type LoginGuard struct {
+	cfg     LoginGuardConfig
+	mu      sync.Mutex
+	entries map[string]*loginFailures
+}
+
+const loginGuardSweepSize = 10000
+
+func NewLoginGuard(cfg LoginGuardConfig) *LoginGuard {
+	return &LoginGuard{cfg: cfg, entries: map[string]*loginFailures{}}
+}
+
+// Check returns a *LockedError if key is currently delayed or locked out.
+func (g *LoginGuard) Check(key string) error {
+	if key == "" {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	e, ok := g.entries[key]
+	if !ok {
+		return nil
+	}
+	now := time.Now()
+	if wait := e.blockedUntil.Sub(now); wait > 0 {
+		return &LockedError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// Fail records a failed attempt for key and schedules the next allowed attempt.
+func (g *LoginGuard) Fail(key string) {
+	if key == "" {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := time.Now()
+	if len(g.entries) >= loginGuardSweepSize {
+		g.sweep(now)
+	}
+	e, ok := g.entries[key]
+	if !ok || now.Sub(e.lastFailure) > g.cfg.Window {
+		e = &loginFailures{}
+		g.entries[key] = e
+	}
+	e.count++
+	e.lastFailure = now
+
+	switch {
+	case g.cfg.LockoutThreshold > 0 && e.count >= g.cfg.LockoutThreshold:
+		e.blockedUntil = now.Add(g.cfg.LockoutDuration)
+	case e.count > g.cfg.FreeAttempts:
+		delay := g.cfg.BaseDelay << uint(e.count-g.cfg.FreeAttempts-1)
+		if delay <= 0 || delay > g.cfg.MaxDelay {
+			delay = g.cfg.MaxDelay
+		}
+		e.blockedUntil = now.Add(delay)
+	}
+}
+
+// Reset forgets failures for key (after a successful login).
+func (g *LoginGuard) Reset(key string) {
+	g.mu.Lock()
+	delete(g.entries, key)
+	g.mu.Unlock()
+}
+
+// sweep drops entries that are neither blocked nor inside the failure window. Caller holds mu.
+func (g *LoginGuard) sweep(now time.Time) {
+	for k, e := range g.entries {
+		if now.After(e.blockedUntil) && now.Sub(e.lastFailure) > g.cfg.Window {
+			delete(g.entries, k)
+		}
+	}
+}
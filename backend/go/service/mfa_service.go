@@ -0,0 +1,226 @@
+/* Place: backend/go/service/mfa_service.go */
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gatherup/auth"
+	"gatherup/repository"
+)
+
+var ErrTOTPAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+var ErrTOTPNotEnrolled = errors.New("no pending two-factor enrollment")
+var ErrTOTPNotEnabled = errors.New("two-factor authentication is not enabled")
+var ErrMFAInvalid = errors.New("invalid two-factor code")
+var ErrMFAChallengeInvalid = errors.New("mfa challenge expired or invalid")
+
+// MFARequiredError is returned by Login when the password was correct but a second factor
+// is needed. Token must be exchanged through LoginMFA before ExpiresAt.
+type MFARequiredError struct {
+	Token     string
+	ExpiresAt time.Time
+}
+
+func (e *MFARequiredError) Error() string { return "two-factor authentication required" }
+
+// TOTPEnrollment is what the client needs to add the account to an authenticator app.
+type TOTPEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// requireSecondFactor returns a *MFARequiredError if the user has TOTP enabled.
+func (s *AuthService) requireSecondFactor(ctx context.Context, userID string) error {
+	cred, err := s.repo.GetTOTPCredential(ctx, userID, repository.CredentialTOTP)
+	if err != nil {
+		return err
+	}
+	if cred == nil {
+		return nil
+	}
+	token, exp, err := s.jwtManager.GeneratePurpose(userID, auth.PurposeMFA, s.cfg.MFAChallengeTTL)
+	if err != nil {
+		return err
+	}
+	return &MFARequiredError{Token: token, ExpiresAt: exp}
+}
+
+// LoginMFA completes a two-step login: it checks the challenge token from Login and a TOTP
+// code (or one recovery code) and issues the real tokens. Failures count against the same
+// guards as password attempts, keyed by user.
+func (s *AuthService) LoginMFA(ctx context.Context, challenge, code, recoveryCode string, client ClientInfo) (accessToken string, accessExp time.Time, refreshRaw string, refreshExpiry time.Time, err error) {
+	claims, err := s.jwtManager.VerifyPurpose(challenge, auth.PurposeMFA)
+	if err != nil {
+		return "", time.Time{}, "", time.Time{}, ErrMFAChallengeInvalid
+	}
+	userID := claims.UserID
+	idKey := "mfa:" + userID
+	if err := s.checkLoginGuards(idKey, client.IP); err != nil {
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	if err := s.verifySecondFactor(ctx, userID, code, recoveryCode); err != nil {
+		if errors.Is(err, ErrMFAInvalid) {
+			s.recordLoginFailure(idKey, client.IP)
+		}
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	s.idGuard.Reset(idKey)
+	return s.completeLogin(ctx, userID, client)
+}
+
+// verifySecondFactor accepts either a current TOTP code or an unused recovery code.
+func (s *AuthService) verifySecondFactor(ctx context.Context, userID, code, recoveryCode string) error {
+	if recoveryCode != "" {
+		ok, err := s.repo.ConsumeRecoveryCode(ctx, userID, auth.HashRefreshToken(auth.NormalizeRecoveryCode(recoveryCode)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return ErrMFAInvalid
+		}
+		return nil
+	}
+	cred, err := s.repo.GetTOTPCredential(ctx, userID, repository.CredentialTOTP)
+	if err != nil {
+		return err
+	}
+	if cred == nil {
+		return ErrTOTPNotEnabled
+	}
+	return s.checkTOTP(ctx, cred, code)
+}
+
+// checkTOTP validates code against the credential and burns its time step.
+func (s *AuthService) checkTOTP(ctx context.Context, cred *repository.TOTPCredential, code string) error {
+	secret, err := s.cfg.SecretBox.Open(cred.Secret)
+	if err != nil {
+		return err
+	}
+	step, ok := auth.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return ErrMFAInvalid
+	}
+	fresh, err := s.repo.MarkTOTPStepUsed(ctx, cred.ID, auth.TOTPStepTime(step))
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrMFAInvalid
+	}
+	return nil
+}
+
+// EnrollTOTP creates (or replaces) a pending TOTP secret. It becomes active after ConfirmTOTP.
+func (s *AuthService) EnrollTOTP(ctx context.Context, userID string) (*TOTPEnrollment, error) {
+	active, err := s.repo.GetTOTPCredential(ctx, userID, repository.CredentialTOTP)
+	if err != nil {
+		return nil, err
+	}
+	if active != nil {
+		return nil, ErrTOTPAlreadyEnabled
+	}
+	u, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	account := userID
+	if u.Username != nil && *u.Username != "" {
+		account = *u.Username
+	} else if u.MobileNumber != nil && *u.MobileNumber != "" {
+		account = *u.MobileNumber
+	}
+
+	secret, err := auth.GenerateTOTPSecret()
+	if err != nil {
+		return nil, err
+	}
+	sealed, err := s.cfg.SecretBox.Seal(secret)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.SavePendingTOTP(ctx, userID, sealed); err != nil {
+		return nil, err
+	}
+	return &TOTPEnrollment{Secret: secret, URI: auth.TOTPProvisioningURI(s.cfg.TOTPIssuer, account, secret)}, nil
+}
+
+// ConfirmTOTP activates the pending secret once the user proves their app produces valid codes.
+// It returns the recovery codes in plain text; they are only stored hashed and shown once.
+func (s *AuthService) ConfirmTOTP(ctx context.Context, userID, code string) ([]string, error) {
+	pending, err := s.repo.GetTOTPCredential(ctx, userID, repository.CredentialTOTPPending)
+	if err != nil {
+		return nil, err
+	}
+	if pending == nil {
+		return nil, ErrTOTPNotEnrolled
+	}
+	secret, err := s.cfg.SecretBox.Open(pending.Secret)
+	if err != nil {
+		return nil, err
+	}
+	step, ok := auth.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return nil, ErrMFAInvalid
+	}
+	codes, hashes, err := s.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ActivateTOTP(ctx, userID, pending.ID, auth.TOTPStepTime(step), hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor off after checking a current code or a recovery code.
+func (s *AuthService) DisableTOTP(ctx context.Context, userID, code, recoveryCode string) error {
+	if err := s.verifySecondFactor(ctx, userID, code, recoveryCode); err != nil {
+		return err
+	}
+	return s.repo.DisableTOTP(ctx, userID)
+}
+
+// RegenerateRecoveryCodes replaces all recovery codes after checking a current TOTP code.
+func (s *AuthService) RegenerateRecoveryCodes(ctx context.Context, userID, code string) ([]string, error) {
+	if err := s.verifySecondFactor(ctx, userID, code, ""); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := s.newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// TOTPStatus reports whether 2FA is enabled and how many recovery codes are left.
+func (s *AuthService) TOTPStatus(ctx context.Context, userID string) (bool, int, error) {
+	cred, err := s.repo.GetTOTPCredential(ctx, userID, repository.CredentialTOTP)
+	if err != nil || cred == nil {
+		return false, 0, err
+	}
+	n, err := s.repo.CountCredentials(ctx, userID, repository.CredentialRecoveryCode)
+	if err != nil {
+		return false, 0, err
+	}
+	return true, n, nil
+}
+
+func (s *AuthService) newRecoveryCodes() (codes, hashes []string, err error) {
+	for i := 0; i < s.cfg.RecoveryCodeCount; i++ {
+		c, err := auth.GenerateRecoveryCode()
+		if err != nil {
+			return nil, nil, err
+		}
+		codes = append(codes, c)
+		hashes = append(hashes, auth.HashRefreshToken(auth.NormalizeRecoveryCode(c)))
+	}
+	return codes, hashes, nil
+}
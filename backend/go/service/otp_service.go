@@ -0,0 +1,168 @@
+/* Place: backend/go/service/otp_service.go */
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"gatherup/auth"
+	"gatherup/models"
+	"gatherup/notify"
+	"gatherup/repository"
+)
+
+// OTP purposes; a code issued for one purpose never verifies another.
+const (
+	OTPPurposeVerifyMobile  = "verify_mobile"
+	OTPPurposePasswordReset = "password_reset"
+	OTPPurposeVerifyEmail   = "verify_email"
+	OTPPurposeLogin         = "login"
+)
+
+// OTP delivery channels.
+const (
+	OTPChannelSMS   = "sms"
+	OTPChannelEmail = "email"
+)
+
+// OTPConfig controls code length, lifetime and limits.
+type OTPConfig struct {
+	Digits         int
+	TTL            time.Duration
+	MaxAttempts    int
+	ResendInterval time.Duration
+	MaxPerHour     int
+	// Secret keys the HMAC used to store codes.
+	Secret string
+}
+
+// OTPService issues and verifies hashed, expiring, attempt-limited one-time codes.
+type OTPService struct {
+	repo  *repository.OTPRepo
+	sms   notify.SMSSender
+	email notify.EmailSender
+	cfg   *OTPConfig
+}
+
+func NewOTPService(repo *repository.OTPRepo, sms notify.SMSSender, email notify.EmailSender, cfg *OTPConfig) *OTPService {
+	return &OTPService{repo: repo, sms: sms, email: email, cfg: cfg}
+}
+
+var ErrOTPInvalid = errors.New("invalid code")
+var ErrOTPExpired = errors.New("code expired")
+var ErrOTPTooManyAttempts = errors.New("too many attempts; request a new code")
+var ErrOTPRateLimited = errors.New("too many codes requested; try again later")
+
+// Send generates a code for purpose and delivers it over channel to destination.
+// userID is optional and only recorded for auditing.
+func (s *OTPService) Send(ctx context.Context, purpose, channel, destination string, userID *string) error {
+	now := time.Now().UTC()
+	// resend throttling is per destination, whoever asked, so a number can't be flooded
+	latest, err := s.repo.GetLatestActive(ctx, destination, purpose, nil)
+	if err != nil {
+		return err
+	}
+	if latest != nil && now.Sub(latest.CreatedAt) < s.cfg.ResendInterval {
+		return ErrOTPRateLimited
+	}
+	n, err := s.repo.CountSince(ctx, destination, purpose, now.Add(-time.Hour))
+	if err != nil {
+		return err
+	}
+	if s.cfg.MaxPerHour > 0 && n >= s.cfg.MaxPerHour {
+		return ErrOTPRateLimited
+	}
+
+	code, err := auth.GenerateOTP(s.cfg.Digits)
+	if err != nil {
+		return err
+	}
+	if _, err := s.repo.Create(ctx, &models.OTPCode{
+		UserID:      userID,
+		Purpose:     purpose,
+		Channel:     channel,
+		Destination: destination,
+		CodeHash:    auth.HashOTP(s.cfg.Secret, purpose, destination, code),
+		MaxAttempts: s.cfg.MaxAttempts,
+		ExpiresAt:   now.Add(s.cfg.TTL),
+	}); err != nil {
+		return err
+	}
+	return s.deliver(ctx, channel, destination, otpMessage(code, s.cfg.TTL))
+}
+
+// SendQuietly is Send for endpoints that answer the same for unknown destinations: a rate
+// limit is swallowed, since only registered destinations can ever hit one.
+func (s *OTPService) SendQuietly(ctx context.Context, purpose, channel, destination string, userID *string) error {
+	if err := s.Send(ctx, purpose, channel, destination, userID); err != nil && !errors.Is(err, ErrOTPRateLimited) {
+		return err
+	}
+	return nil
+}
+
+// Verify checks code for destination/purpose and consumes it on success.
+func (s *OTPService) Verify(ctx context.Context, purpose, destination, code string) error {
+	return s.verify(ctx, purpose, destination, code, "")
+}
+
+// VerifyForUser is Verify for codes that were requested by a signed-in user: the code only
+// verifies for that same user.
+func (s *OTPService) VerifyForUser(ctx context.Context, purpose, destination, code, userID string) error {
+	return s.verify(ctx, purpose, destination, code, userID)
+}
+
+func (s *OTPService) verify(ctx context.Context, purpose, destination, code, userID string) error {
+	var owner *string
+	if userID != "" {
+		owner = &userID
+	}
+	c, err := s.repo.GetLatestActive(ctx, destination, purpose, owner)
+	if err != nil {
+		return err
+	}
+	if c == nil || code == "" {
+		return ErrOTPInvalid
+	}
+	if userID != "" && (c.UserID == nil || *c.UserID != userID) {
+		return ErrOTPInvalid
+	}
+	if time.Now().UTC().After(c.ExpiresAt) {
+		return ErrOTPExpired
+	}
+	// Every guess claims an attempt before the comparison, right or wrong.
+	claimed, err := s.repo.ClaimAttempt(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		return ErrOTPTooManyAttempts
+	}
+	if !auth.CompareOTPHash(c.CodeHash, auth.HashOTP(s.cfg.Secret, purpose, destination, code)) {
+		return ErrOTPInvalid
+	}
+	ok, err := s.repo.Consume(ctx, c.ID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrOTPInvalid
+	}
+	return nil
+}
+
+func (s *OTPService) deliver(ctx context.Context, channel, destination, body string) error {
+	switch channel {
+	case OTPChannelSMS:
+		return s.sms.SendSMS(ctx, destination, body)
+	case OTPChannelEmail:
+		return s.email.SendEmail(ctx, destination, "Your GatherUp code", body)
+	default:
+		return fmt.Errorf("unsupported otp channel %q", channel)
+	}
+}
+
+func otpMessage(code string, ttl time.Duration) string {
+	return fmt.Sprintf("Your GatherUp code is %s. It expires in %d minutes. Do not share it.", code, int(ttl.Minutes()))
+}
@@ -0,0 +1,92 @@
+/* Place: backend/go/service/password_service.go */
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+const minPasswordLen = 8
+const maxPasswordLen = 128
+
+var ErrWeakPassword = errors.New("password does not meet the password policy")
+
+// WeakPasswordError explains why a new password was rejected; it wraps ErrWeakPassword.
+type WeakPasswordError struct {
+	Reason string
+}
+
+func (e *WeakPasswordError) Error() string { return e.Reason }
+
+func (e *WeakPasswordError) Unwrap() error { return ErrWeakPassword }
+
+// validatePassword applies the password policy for new passwords, including the byte limit of
+// the active hash algorithm (72 bytes for bcrypt, fewer characters with accents or emoji).
+func (s *AuthService) validatePassword(p string) error {
+	if n := len([]rune(p)); n < minPasswordLen || n > maxPasswordLen {
+		return &WeakPasswordError{Reason: fmt.Sprintf("password must be between %d and %d characters", minPasswordLen, maxPasswordLen)}
+	}
+	if max := s.hasher.MaxPasswordBytes(); max > 0 && len(p) > max {
+		return &WeakPasswordError{Reason: fmt.Sprintf("password must be at most %d bytes (fewer characters if it uses accents or emoji)", max)}
+	}
+	return nil
+}
+
+// ChangePassword rotates the password of an authenticated user after checking the current one.
+// Every refresh token (all sessions, including the caller's) and API key is revoked on success.
+func (s *AuthService) ChangePassword(ctx context.Context, userID, current, newPassword string) error {
+	if err := s.validatePassword(newPassword); err != nil {
+		return err
+	}
+	pwHash, err := s.repo.GetPasswordHashByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if pwHash == "" {
+		return ErrInvalidCredentials
+	}
+	if ok, _, _ := s.hasher.Verify(pwHash, current); !ok {
+		return ErrInvalidCredentials
+	}
+	return s.setPassword(ctx, userID, newPassword)
+}
+
+// ForgotPassword sends a reset code to a registered mobile number.
+// Unknown numbers succeed silently so the endpoint can't enumerate accounts.
+func (s *AuthService) ForgotPassword(ctx context.Context, mobile string) error {
+	mobileNorm := NormalizeMobile(mobile)
+	userID, _, err := s.repo.GetCredentialByIdentifier(ctx, mobileNorm)
+	if err != nil || userID == "" {
+		return err
+	}
+	return s.otp.SendQuietly(ctx, OTPPurposePasswordReset, OTPChannelSMS, mobileNorm, &userID)
+}
+
+// ResetPassword sets a new password using a code from ForgotPassword and revokes all sessions.
+func (s *AuthService) ResetPassword(ctx context.Context, mobile, code, newPassword string) error {
+	if err := s.validatePassword(newPassword); err != nil {
+		return err
+	}
+	mobileNorm := NormalizeMobile(mobile)
+	if err := s.otp.Verify(ctx, OTPPurposePasswordReset, mobileNorm, code); err != nil {
+		return err
+	}
+	userID, _, err := s.repo.GetCredentialByIdentifier(ctx, mobileNorm)
+	if err != nil {
+		return err
+	}
+	if userID == "" {
+		return ErrOTPInvalid
+	}
+	return s.setPassword(ctx, userID, newPassword)
+}
+
+// setPassword stores a new hash and revokes every refresh token of the user in one transaction.
+func (s *AuthService) setPassword(ctx context.Context, userID, newPassword string) error {
+	phash, err := s.hasher.Hash(newPassword)
+	if err != nil {
+		return err
+	}
+	return s.repo.UpdatePasswordHash(ctx, userID, phash)
+}
@@ -0,0 +1,130 @@
+/* Place: backend/go/service/passwordless_service.go */
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"gatherup/repository"
+)
+
+var ErrPasswordlessDisabled = errors.New("passwordless login is disabled")
+
+// otpLoginTarget is where a login code for an identifier is delivered.
+type otpLoginTarget struct {
+	userID      string
+	channel     string
+	destination string
+}
+
+// guardKey is loginGuardKey for t, which may be nil when the identifier has no usable account.
+func (t *otpLoginTarget) guardKey(idType, idValue string) string {
+	if t == nil {
+		return loginGuardKey(idType, idValue, "")
+	}
+	return loginGuardKey(idType, idValue, t.userID)
+}
+
+// resolveOTPLogin maps a login identifier to a verified delivery address. Mobile numbers must
+// be verified; emails are only linked after verification. A username uses the verified mobile,
+// falling back to the linked email. Returns nil when there is nowhere safe to send a code.
+func (s *AuthService) resolveOTPLogin(ctx context.Context, idType, idValue string) (*otpLoginTarget, error) {
+	var userID string
+	var err error
+	switch idType {
+	case identifierEmail:
+		userID, err = s.repo.GetUserIDByCredential(ctx, repository.CredentialEmail, idValue)
+		if err != nil || userID == "" {
+			return nil, err
+		}
+		return &otpLoginTarget{userID: userID, channel: OTPChannelEmail, destination: idValue}, nil
+	case identifierMobile:
+		userID, _, err = s.repo.GetCredentialByIdentifier(ctx, idValue)
+	default:
+		userID, err = s.repo.GetUserIDByUsername(ctx, idValue)
+	}
+	if err != nil || userID == "" {
+		return nil, err
+	}
+	u, err := s.repo.GetByID(ctx, userID)
+	if err != nil || u == nil {
+		return nil, err
+	}
+	if u.IsMobileVerified && u.MobileNormalized != nil && *u.MobileNormalized != "" {
+		if idType == identifierMobile && *u.MobileNormalized != idValue {
+			return nil, nil
+		}
+		return &otpLoginTarget{userID: userID, channel: OTPChannelSMS, destination: *u.MobileNormalized}, nil
+	}
+	if idType == identifierUsername && u.Email != nil && u.IsEmailVerified != nil && *u.IsEmailVerified {
+		return &otpLoginTarget{userID: userID, channel: OTPChannelEmail, destination: NormalizeEmail(*u.Email)}, nil
+	}
+	return nil, nil
+}
+
+// RequestLoginOTP sends a login code for identifier (mobile, email or username).
+// Unknown or unverified identifiers succeed silently so the endpoint can't enumerate accounts.
+// Locked accounts / IPs get the same *LockedError as password login.
+func (s *AuthService) RequestLoginOTP(ctx context.Context, identifier, ip string) error {
+	if !s.cfg.PasswordlessLogin {
+		return ErrPasswordlessDisabled
+	}
+	idType, idValue := classifyIdentifier(identifier)
+	target, err := s.resolveOTPLogin(ctx, idType, idValue)
+	if err != nil {
+		return err
+	}
+	if err := s.checkLoginGuards(target.guardKey(idType, idValue), ip); err != nil {
+		return err
+	}
+	if target == nil {
+		return nil
+	}
+	return s.otp.SendQuietly(ctx, OTPPurposeLogin, target.channel, target.destination, &target.userID)
+}
+
+// LoginWithOTP exchanges a code from RequestLoginOTP for tokens, exactly like Login would.
+// Wrong codes count as failed logins for the account and IP; every failure is reported as
+// ErrInvalidCredentials.
+func (s *AuthService) LoginWithOTP(ctx context.Context, identifier, code string, client ClientInfo) (accessToken string, accessExp time.Time, refreshRaw string, refreshExpiry time.Time, err error) {
+	if !s.cfg.PasswordlessLogin {
+		return "", time.Time{}, "", time.Time{}, ErrPasswordlessDisabled
+	}
+	if identifier == "" || code == "" {
+		return "", time.Time{}, "", time.Time{}, ErrInvalidCredentials
+	}
+	idType, idValue := classifyIdentifier(identifier)
+	target, err := s.resolveOTPLogin(ctx, idType, idValue)
+	if err != nil {
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	idKey := target.guardKey(idType, idValue)
+	if err := s.checkLoginGuards(idKey, client.IP); err != nil {
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	if target == nil {
+		s.recordLoginFailure(idKey, client.IP)
+		return "", time.Time{}, "", time.Time{}, ErrInvalidCredentials
+	}
+	if err := s.otp.VerifyForUser(ctx, OTPPurposeLogin, target.destination, code, target.userID); err != nil {
+		if errors.Is(err, ErrOTPInvalid) || errors.Is(err, ErrOTPExpired) || errors.Is(err, ErrOTPTooManyAttempts) {
+			s.recordLoginFailure(idKey, client.IP)
+			return "", time.Time{}, "", time.Time{}, ErrInvalidCredentials
+		}
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	s.idGuard.Reset(idKey)
+	if target.channel == OTPChannelEmail {
+		if err := s.repo.TouchCredentialLastUsed(ctx, target.userID, repository.CredentialEmail); err != nil {
+			return "", time.Time{}, "", time.Time{}, err
+		}
+	}
+	if err := s.checkMobileVerified(ctx, target.userID); err != nil {
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	if err := s.requireSecondFactor(ctx, target.userID); err != nil {
+		return "", time.Time{}, "", time.Time{}, err
+	}
+	return s.completeLogin(ctx, target.userID, client)
+}
@@ -0,0 +1,240 @@
+/* Place: backend/go/service/preferences_service.go */
+package service
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+	_ "time/tzdata" // IANA zones without relying on the host's zoneinfo
+
+	"gatherup/models"
+	"gatherup/repository"
+
+	"golang.org/x/text/language"
+)
+
+// PreferencesConfig controls the preferences cache.
+type PreferencesConfig struct {
+	// CacheTTL is how long a lookup is served from memory. Updates made through this process
+	// are visible at once; other instances see them after at most CacheTTL.
+	CacheTTL time.Duration
+}
+
+// PreferencesService reads and edits user preferences. Notification and i18n code should use
+// WantsNotification and Locale, which are served from an in-memory cache.
+type PreferencesService struct {
+	users *repository.UserRepo
+	cfg   *PreferencesConfig
+
+	mu    sync.Mutex
+	cache map[string]cachedPreferences
+	// gen counts invalidations. A lookup only caches what it read if gen is unchanged, so a
+	// read that raced an Update can't put the pre-update row back.
+	gen uint64
+}
+
+type cachedPreferences struct {
+	prefs   models.UserPreferences
+	expires time.Time
+}
+
+func NewPreferencesService(users *repository.UserRepo, cfg *PreferencesConfig) *PreferencesService {
+	return &PreferencesService{users: users, cfg: cfg, cache: map[string]cachedPreferences{}}
+}
+
+// PreferencesUpdate is a partial preferences update; absent fields are left unchanged and
+// null resets a field to its default.
+type PreferencesUpdate struct {
+	NotifyOnMessage Optional[bool]   `json:"notify_on_message"`
+	NotifyOnLike    Optional[bool]   `json:"notify_on_like"`
+	NotifyOnComment Optional[bool]   `json:"notify_on_comment"`
+	Timezone        Optional[string] `json:"timezone"`
+	Lang            Optional[string] `json:"lang"`
+	Theme           Optional[string] `json:"theme"`
+}
+
+// Preference column limits (dbo.user_preferences).
+const (
+	maxTimezoneLen = 50
+	maxLangLen     = 10
+)
+
+var validThemes = map[string]bool{
+	models.ThemeLight: true, models.ThemeDark: true, models.ThemeSystem: true,
+}
+
+// Get returns userID's preferences; users who never saved any get the defaults.
+func (s *PreferencesService) Get(ctx context.Context, userID string) (*models.UserPreferences, error) {
+	now := time.Now()
+	s.mu.Lock()
+	c, ok := s.cache[userID]
+	gen := s.gen
+	s.mu.Unlock()
+	if ok && now.Before(c.expires) {
+		p := c.prefs
+		return &p, nil
+	}
+
+	p, err := s.users.GetPreferences(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if p == nil {
+		d := models.DefaultPreferences()
+		p = &d
+	}
+	s.store(userID, *p, now, gen)
+	return p, nil
+}
+
+// Update validates and applies upd, creating the user's row on first use, and returns the
+// resulting preferences.
+func (s *PreferencesService) Update(ctx context.Context, userID string, upd PreferencesUpdate) (*models.UserPreferences, error) {
+	changes := map[string]interface{}{}
+	for col, o := range map[string]Optional[bool]{
+		"notify_on_message": upd.NotifyOnMessage,
+		"notify_on_like":    upd.NotifyOnLike,
+		"notify_on_comment": upd.NotifyOnComment,
+	} {
+		if o.Set {
+			changes[col] = nullableBool(o.Value)
+		}
+	}
+	if upd.Timezone.Set {
+		v, err := cleanTimezone(upd.Timezone.Value)
+		if err != nil {
+			return nil, err
+		}
+		changes["timezone"] = v
+	}
+	if upd.Lang.Set {
+		v, err := cleanLang(upd.Lang.Value)
+		if err != nil {
+			return nil, err
+		}
+		changes["lang"] = v
+	}
+	if upd.Theme.Set {
+		var v interface{}
+		if upd.Theme.Value != nil && strings.TrimSpace(*upd.Theme.Value) != "" {
+			t := strings.ToLower(strings.TrimSpace(*upd.Theme.Value))
+			if !validThemes[t] {
+				return nil, &FieldError{Field: "theme", Reason: "must be light, dark or system"}
+			}
+			v = t
+		}
+		changes["theme"] = v
+	}
+
+	if err := s.users.SavePreferences(ctx, userID, changes); err != nil {
+		return nil, err
+	}
+	s.invalidate(userID)
+	return s.Get(ctx, userID)
+}
+
+// invalidate drops userID from the cache and discards lookups still in flight.
+func (s *PreferencesService) invalidate(userID string) {
+	s.mu.Lock()
+	delete(s.cache, userID)
+	s.gen++
+	s.mu.Unlock()
+}
+
+// WantsNotification reports whether userID wants notifications of kind (see the
+// models.Notification* kinds). Kinds without a preference are always wanted. If the lookup
+// fails the default (wanted) is returned.
+func (s *PreferencesService) WantsNotification(ctx context.Context, userID, kind string) bool {
+	p, err := s.Get(ctx, userID)
+	if err != nil {
+		d := models.DefaultPreferences()
+		p = &d
+	}
+	switch kind {
+	case models.NotificationMessage:
+		return p.NotifyOnMessage
+	case models.NotificationLike:
+		return p.NotifyOnLike
+	case models.NotificationComment:
+		return p.NotifyOnComment
+	default:
+		return true
+	}
+}
+
+// Locale returns userID's language tag and time zone for formatting, falling back to the
+// defaults if the lookup fails.
+func (s *PreferencesService) Locale(ctx context.Context, userID string) (language.Tag, *time.Location) {
+	p, err := s.Get(ctx, userID)
+	if err != nil {
+		d := models.DefaultPreferences()
+		p = &d
+	}
+	tag, err := language.Parse(p.Lang)
+	if err != nil {
+		tag = language.Make(models.DefaultLang)
+	}
+	loc, err := time.LoadLocation(p.Timezone)
+	if err != nil {
+		loc = time.UTC
+	}
+	return tag, loc
+}
+
+// store caches p for userID unless the cache was invalidated since gen was read.
+func (s *PreferencesService) store(userID string, p models.UserPreferences, now time.Time, gen uint64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if gen != s.gen {
+		return
+	}
+	if len(s.cache) > loginGuardSweepSize {
+		for id, c := range s.cache {
+			if !now.Before(c.expires) {
+				delete(s.cache, id)
+			}
+		}
+	}
+	s.cache[userID] = cachedPreferences{prefs: p, expires: now.Add(s.cfg.CacheTTL)}
+}
+
+// cleanTimezone validates an IANA zone name; nil or empty resets it.
+func cleanTimezone(v *string) (interface{}, error) {
+	if v == nil || strings.TrimSpace(*v) == "" {
+		return nil, nil
+	}
+	tz := strings.TrimSpace(*v)
+	// "Local" is the server's zone, not a place
+	if len(tz) > maxTimezoneLen || tz == "Local" {
+		return nil, &FieldError{Field: "timezone", Reason: "must be an IANA time zone such as Europe/London"}
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, &FieldError{Field: "timezone", Reason: "must be an IANA time zone such as Europe/London"}
+	}
+	return loc.String(), nil
+}
+
+// cleanLang validates a BCP 47 language tag and stores its canonical form; nil or empty resets it.
+func cleanLang(v *string) (interface{}, error) {
+	if v == nil || strings.TrimSpace(*v) == "" {
+		return nil, nil
+	}
+	tag, err := language.Parse(strings.TrimSpace(*v))
+	if err != nil || tag == language.Und {
+		return nil, &FieldError{Field: "lang", Reason: "must be a BCP 47 language tag such as en or pt-BR"}
+	}
+	s := tag.String()
+	if len(s) > maxLangLen {
+		return nil, &FieldError{Field: "lang", Reason: "language tag is too long"}
+	}
+	return s, nil
+}
+
+func nullableBool(v *bool) interface{} {
+	if v == nil {
+		return nil
+	}
+	return *v
+}
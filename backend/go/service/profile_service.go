@@ -0,0 +1,303 @@
+/* Place: backend/go/service/profile_service.go */
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+
+	"gatherup/models"
+	"gatherup/repository"
+)
+
+// ProfileConfig controls profile edits.
+type ProfileConfig struct {
+	// UsernameChangeInterval is the minimum time between two username changes. Claiming the
+	// first username is not limited.
+	UsernameChangeInterval time.Duration
+}
+
+// ProfileService reads and edits the caller's own profile.
+type ProfileService struct {
+	users *repository.UserRepo
+	// discovery rate-limits search by mobile number together with contact discovery.
+	discovery *DiscoveryService
+	cfg       *ProfileConfig
+}
+
+func NewProfileService(users *repository.UserRepo, discovery *DiscoveryService, cfg *ProfileConfig) *ProfileService {
+	return &ProfileService{users: users, discovery: discovery, cfg: cfg}
+}
+
+var ErrUsernameChangeLimited = errors.New("username was changed too recently")
+var ErrPreconditionFailed = errors.New("resource was modified by another request")
+
+// UsernameCooldownError is returned while a new username change is not yet allowed; it wraps
+// ErrUsernameChangeLimited.
+type UsernameCooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *UsernameCooldownError) Error() string {
+	return fmt.Sprintf("username can be changed again in %s", e.RetryAfter.Round(time.Minute))
+}
+
+func (e *UsernameCooldownError) Unwrap() error { return ErrUsernameChangeLimited }
+
+// FieldError reports an invalid profile field.
+type FieldError struct {
+	Field  string
+	Reason string
+}
+
+func (e *FieldError) Error() string { return e.Field + ": " + e.Reason }
+
+// Optional is a PATCH field: Set tells an absent field from an explicit null (Value nil).
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *Optional[T]) UnmarshalJSON(b []byte) error {
+	o.Set = true
+	if string(b) == "null" {
+		o.Value = nil
+		return nil
+	}
+	var v T
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	o.Value = &v
+	return nil
+}
+
+// ProfileUpdate is a partial profile update; absent fields are left unchanged and null or
+// empty values clear the field (except username, which can only be changed).
+type ProfileUpdate struct {
+	DisplayName Optional[string] `json:"display_name"`
+	Username    Optional[string] `json:"username"`
+	AvatarURL   Optional[string] `json:"avatar_url"`
+	Bio         Optional[string] `json:"bio"`
+	Gender      Optional[string] `json:"gender"`
+	// DateOfBirth is YYYY-MM-DD.
+	DateOfBirth Optional[string] `json:"date_of_birth"`
+}
+
+// Profile field limits.
+const (
+	maxDisplayNameLen = 100
+	maxBioLen         = 500
+	maxAvatarURLLen   = 2048
+	minAgeYears       = 13
+	maxAgeYears       = 120
+)
+
+var usernamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_.]{2,29}$`)
+
+// reservedUsernames could be mistaken for staff or system accounts.
+var reservedUsernames = map[string]bool{
+	"admin": true, "administrator": true, "support": true, "help": true, "gatherup": true,
+	"moderator": true, "system": true, "root": true, "me": true, "api": true,
+}
+
+var validGenders = map[string]bool{
+	"female": true, "male": true, "non_binary": true, "other": true, "prefer_not_to_say": true,
+}
+
+// GetProfile returns the caller's full profile.
+func (s *ProfileService) GetProfile(ctx context.Context, userID string) (*models.User, error) {
+	u, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil {
+		return nil, ErrUserNotFound
+	}
+	return u, nil
+}
+
+// UpdateProfile validates and applies upd, then returns the updated profile. A non-nil ifMatch
+// is the rowversion the client last saw; the update fails with ErrPreconditionFailed if the
+// profile changed since.
+func (s *ProfileService) UpdateProfile(ctx context.Context, userID string, upd ProfileUpdate, ifMatch []byte) (*models.User, error) {
+	u, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if ifMatch != nil && !bytes.Equal(ifMatch, u.Rv) {
+		return nil, ErrPreconditionFailed
+	}
+	changes := map[string]interface{}{}
+
+	if upd.DisplayName.Set {
+		v, err := cleanText("display_name", upd.DisplayName.Value, maxDisplayNameLen, false)
+		if err != nil {
+			return nil, err
+		}
+		changes["display_name"] = v
+	}
+	if upd.Bio.Set {
+		v, err := cleanText("bio", upd.Bio.Value, maxBioLen, true)
+		if err != nil {
+			return nil, err
+		}
+		changes["bio"] = v
+	}
+	if upd.AvatarURL.Set {
+		v, err := cleanAvatarURL(upd.AvatarURL.Value)
+		if err != nil {
+			return nil, err
+		}
+		changes["avatar_url"] = v
+	}
+	if upd.Gender.Set {
+		var v interface{}
+		if g := optionalValue(upd.Gender.Value); g != "" {
+			if !validGenders[g] {
+				return nil, &FieldError{Field: "gender", Reason: "must be one of female, male, non_binary, other, prefer_not_to_say"}
+			}
+			v = g
+		}
+		changes["gender"] = v
+	}
+	if upd.DateOfBirth.Set {
+		v, err := parseDateOfBirth(optionalValue(upd.DateOfBirth.Value), time.Now().UTC())
+		if err != nil {
+			return nil, err
+		}
+		changes["date_of_birth"] = v
+	}
+	if upd.Username.Set {
+		name := optionalValue(upd.Username.Value)
+		if err := ValidateUsername(name); err != nil {
+			return nil, err
+		}
+		if u.Username == nil || !strings.EqualFold(*u.Username, name) {
+			if err := s.checkUsernameCooldown(u); err != nil {
+				return nil, err
+			}
+		}
+		changes["username"] = name
+	}
+
+	if len(changes) == 0 {
+		return u, nil
+	}
+	var cutoff time.Time
+	if _, renaming := changes["username"]; renaming && s.cfg.UsernameChangeInterval > 0 {
+		cutoff = time.Now().UTC().Add(-s.cfg.UsernameChangeInterval)
+	}
+	ok, err := s.users.UpdateProfile(ctx, userID, changes, ifMatch, cutoff)
+	if errors.Is(err, repository.ErrDuplicate) {
+		return nil, ErrUsernameTaken
+	}
+	if errors.Is(err, repository.ErrUsernameCooldown) {
+		// another request renamed the user since we loaded the profile
+		if u, err = s.GetProfile(ctx, userID); err != nil {
+			return nil, err
+		}
+		if err := s.checkUsernameCooldown(u); err != nil {
+			return nil, err
+		}
+		return nil, &UsernameCooldownError{RetryAfter: s.cfg.UsernameChangeInterval}
+	}
+	if errors.Is(err, repository.ErrStale) {
+		return nil, ErrPreconditionFailed
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrUserNotFound
+	}
+	return s.GetProfile(ctx, userID)
+}
+
+func (s *ProfileService) checkUsernameCooldown(u *models.User) error {
+	// the first username can be claimed right away
+	if u.Username == nil || u.UsernameChangedAt == nil {
+		return nil
+	}
+	next := u.UsernameChangedAt.Add(s.cfg.UsernameChangeInterval)
+	if wait := time.Until(next); wait > 0 {
+		return &UsernameCooldownError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// ValidateUsername checks the username rules: 3-30 letters, digits, '_' or '.', starting with a
+// letter (so it can never be mistaken for a mobile number or email at login), and not reserved.
+func ValidateUsername(name string) error {
+	if name == "" {
+		return &FieldError{Field: "username", Reason: "cannot be removed"}
+	}
+	if !usernamePattern.MatchString(name) {
+		return &FieldError{Field: "username", Reason: "must be 3-30 letters, digits, '_' or '.', starting with a letter"}
+	}
+	if reservedUsernames[strings.ToLower(name)] {
+		return ErrUsernameTaken
+	}
+	return nil
+}
+
+// cleanText trims v and returns nil for empty values. Control characters are rejected, except
+// newlines when multiline is set.
+func cleanText(field string, v *string, maxLen int, multiline bool) (interface{}, error) {
+	s := strings.TrimSpace(optionalValue(v))
+	if s == "" {
+		return nil, nil
+	}
+	if n := len([]rune(s)); n > maxLen {
+		return nil, &FieldError{Field: field, Reason: fmt.Sprintf("must be at most %d characters", maxLen)}
+	}
+	for _, r := range s {
+		if unicode.IsControl(r) && !(multiline && r == '\n') {
+			return nil, &FieldError{Field: field, Reason: "contains invalid characters"}
+		}
+	}
+	return s, nil
+}
+
+func cleanAvatarURL(v *string) (interface{}, error) {
+	s := strings.TrimSpace(optionalValue(v))
+	if s == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(s)
+	if err != nil || u.Scheme != "https" || u.Host == "" || len(s) > maxAvatarURLLen {
+		return nil, &FieldError{Field: "avatar_url", Reason: "must be an https URL"}
+	}
+	return s, nil
+}
+
+func parseDateOfBirth(s string, now time.Time) (interface{}, error) {
+	if s == "" {
+		return nil, nil
+	}
+	dob, err := time.Parse("2006-01-02", s)
+	if err != nil {
+		return nil, &FieldError{Field: "date_of_birth", Reason: "must be a date in YYYY-MM-DD format"}
+	}
+	if dob.After(now.AddDate(-minAgeYears, 0, 0)) {
+		return nil, &FieldError{Field: "date_of_birth", Reason: fmt.Sprintf("you must be at least %d years old", minAgeYears)}
+	}
+	if dob.Before(now.AddDate(-maxAgeYears, 0, 0)) {
+		return nil, &FieldError{Field: "date_of_birth", Reason: "is not a plausible date of birth"}
+	}
+	return dob, nil
+}
+
+func optionalValue(v *string) string {
+	if v == nil {
+		return ""
+	}
+	return *v
+}
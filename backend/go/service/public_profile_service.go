@@ -0,0 +1,112 @@
+/* Place: backend/go/service/public_profile_service.go */
+package service
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"gatherup/models"
+
+	"github.com/google/uuid"
+)
+
+// GetPublicProfile returns targetID's profile as viewerID may see it. Unknown, deactivated
+// and blocked (in either direction) users all return ErrUserNotFound, so a block is never revealed.
+func (s *ProfileService) GetPublicProfile(ctx context.Context, viewerID, targetID string) (*models.PublicProfile, error) {
+	if _, err := uuid.Parse(targetID); err != nil {
+		return nil, ErrUserNotFound
+	}
+	u, err := s.users.GetByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if u == nil || !u.IsActive {
+		return nil, ErrUserNotFound
+	}
+
+	rel := models.RelationshipSelf
+	if viewerID != u.ID {
+		contact, blocked, err := s.users.GetRelationship(ctx, viewerID, u.ID)
+		if err != nil {
+			return nil, err
+		}
+		if blocked {
+			return nil, ErrUserNotFound
+		}
+		rel = models.RelationshipStranger
+		if contact {
+			rel = models.RelationshipContact
+		}
+	}
+	return s.projectProfile(ctx, u, rel)
+}
+
+// GetPublicProfileByUsername is GetPublicProfile addressed by username.
+func (s *ProfileService) GetPublicProfileByUsername(ctx context.Context, viewerID, username string) (*models.PublicProfile, error) {
+	username = strings.TrimSpace(username)
+	if username == "" {
+		return nil, ErrUserNotFound
+	}
+	id, err := s.users.GetUserIDByUsername(ctx, username)
+	if err != nil {
+		return nil, err
+	}
+	if id == "" {
+		return nil, ErrUserNotFound
+	}
+	return s.GetPublicProfile(ctx, viewerID, id)
+}
+
+// projectProfile builds the view for rel: strangers get the public card, public skills and
+// public post counts; contacts (and the user themselves) also get gender, age, private skills
+// and contacts-only posts.
+func (s *ProfileService) projectProfile(ctx context.Context, u *models.User, rel string) (*models.PublicProfile, error) {
+	trusted := rel != models.RelationshipStranger
+	visibilities := []int{models.VisibilityPublic}
+	if trusted {
+		visibilities = append(visibilities, models.VisibilityContacts)
+	}
+	if rel == models.RelationshipSelf {
+		visibilities = append(visibilities, models.VisibilityPrivate, models.VisibilityGroup)
+	}
+
+	skills, err := s.users.ListSkills(ctx, u.ID, trusted)
+	if err != nil {
+		return nil, err
+	}
+	stats, err := s.users.GetProfileStats(ctx, u.ID, visibilities)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &models.PublicProfile{
+		ID:           u.ID,
+		Username:     u.Username,
+		DisplayName:  u.DisplayName,
+		AvatarURL:    u.AvatarURL,
+		Bio:          u.Bio,
+		IsBot:        u.IsBot,
+		MemberSince:  u.CreatedAt,
+		Relationship: rel,
+		Skills:       skills,
+		Stats:        stats,
+	}
+	if trusted {
+		p.Gender = u.Gender
+		if u.DateOfBirth != nil {
+			age := ageOn(*u.DateOfBirth, time.Now().UTC())
+			p.Age = &age
+		}
+	}
+	return p, nil
+}
+
+// ageOn returns the age in whole years on day now of someone born on dob.
+func ageOn(dob, now time.Time) int {
+	age := now.Year() - dob.Year()
+	if now.Month() < dob.Month() || (now.Month() == dob.Month() && now.Day() < dob.Day()) {
+		age--
+	}
+	return age
+}
@@ -0,0 +1,91 @@
+/* Place: backend/go/service/role_service.go */
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+
+	"gatherup/auth"
+	"gatherup/models"
+)
+
+var ErrInvalidRole = errors.New("invalid role")
+var ErrUserNotFound = errors.New("user not found")
+var ErrRoleNotHeld = errors.New("user does not have that role")
+
+// rolesFor returns the roles to embed in a user's access token; RoleUser is always included.
+func (s *AuthService) rolesFor(ctx context.Context, userID string) ([]string, error) {
+	granted, err := s.repo.GetRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	roles := []string{auth.RoleUser}
+	for _, r := range granted {
+		if r != auth.RoleUser {
+			roles = append(roles, r)
+		}
+	}
+	return roles, nil
+}
+
+// GetRoles returns the effective roles of a user.
+func (s *AuthService) GetRoles(ctx context.Context, userID string) ([]string, error) {
+	return s.rolesFor(ctx, userID)
+}
+
+// GrantRole assigns role to userID on behalf of actorID and records an audit entry.
+// The user's sessions are revoked so the next login carries the new role.
+func (s *AuthService) GrantRole(ctx context.Context, actorID, userID, role string) error {
+	if !auth.IsValidRole(role) || role == auth.RoleUser {
+		return ErrInvalidRole
+	}
+	u, err := s.repo.GetByID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return ErrUserNotFound
+	}
+	if err := s.repo.GrantRole(ctx, userID, role, &actorID); err != nil {
+		return err
+	}
+	if _, err := s.repo.RevokeAllRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+	s.auditRoleChange(ctx, actorID, userID, role, "role_granted")
+	return nil
+}
+
+// RevokeRole removes role from userID on behalf of actorID and records an audit entry.
+// The user's sessions are revoked so the role can't outlive the change through a refresh.
+func (s *AuthService) RevokeRole(ctx context.Context, actorID, userID, role string) error {
+	if !auth.IsValidRole(role) || role == auth.RoleUser {
+		return ErrInvalidRole
+	}
+	ok, err := s.repo.RevokeRole(ctx, userID, role)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrRoleNotHeld
+	}
+	if _, err := s.repo.RevokeAllRefreshTokens(ctx, userID); err != nil {
+		return err
+	}
+	s.auditRoleChange(ctx, actorID, userID, role, "role_revoked")
+	return nil
+}
+
+func (s *AuthService) auditRoleChange(ctx context.Context, actorID, userID, role, action string) {
+	payload, _ := json.Marshal(map[string]string{"role": role})
+	p := string(payload)
+	// best-effort: failures are logged by the repo
+	_ = s.audit.Insert(ctx, &models.AuditLog{
+		EntityType:  "user",
+		EntityID:    userID,
+		Action:      action,
+		PerformedBy: &actorID,
+		Payload:     &p,
+	})
+}
@@ -0,0 +1,96 @@
+/* Place: backend/go/service/user_search_service.go */
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"gatherup/models"
+	"gatherup/repository"
+)
+
+// User search limits.
+const (
+	minSearchQueryLen  = 2
+	maxSearchQueryLen  = 100
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+)
+
+var ErrSearchQuery = errors.New("q must be 2-100 characters")
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// UserSearchPage is one page of search results; NextCursor is empty on the last page.
+type UserSearchPage struct {
+	Users      []models.UserSummary `json:"users"`
+	NextCursor string               `json:"next_cursor,omitempty"`
+}
+
+// SearchUsers finds people for viewerID by username or display name prefix, or by exact
+// mobile number when q looks like one (see NormalizeMobile). Mobile lookups share the hourly
+// per-user limit of contact discovery (a *DiscoveryLimitError). Contacts rank first; blocked
+// users in either direction never appear.
+func (s *ProfileService) SearchUsers(ctx context.Context, viewerID, q, cursor string, limit int) (*UserSearchPage, error) {
+	q = strings.TrimSpace(q)
+	if n := len([]rune(q)); n < minSearchQueryLen || n > maxSearchQueryLen {
+		return nil, ErrSearchQuery
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	params := repository.UserSearchParams{ViewerID: viewerID, Limit: limit + 1}
+	if looksLikeMobile(q) {
+		params.Mobile = NormalizeMobile(q)
+	} else {
+		params.Prefix = q
+	}
+	if cursor != "" {
+		after, err := decodeSearchCursor(cursor)
+		if err != nil {
+			return nil, err
+		}
+		params.After = after
+	}
+	if params.Mobile != "" {
+		if err := s.discovery.TakeMobileLookup(viewerID); err != nil {
+			return nil, err
+		}
+	}
+
+	hits, err := s.users.SearchUsers(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	page := &UserSearchPage{Users: []models.UserSummary{}}
+	for i, h := range hits {
+		if i == limit {
+			page.NextCursor = encodeSearchCursor(hits[i-1].Cursor)
+			break
+		}
+		page.Users = append(page.Users, h.UserSummary)
+	}
+	return page, nil
+}
+
+func encodeSearchCursor(c repository.UserSearchCursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeSearchCursor(s string) (*repository.UserSearchCursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var c repository.UserSearchCursor
+	if err := json.Unmarshal(b, &c); err != nil || c.ID == "" {
+		return nil, ErrInvalidCursor
+	}
+	return &c, nil
+}
@@ -0,0 +1,26 @@
+/* Place: backend/go/storage/storage.go */
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned by Open and Delete when key does not exist.
+var ErrNotFound = errors.New("storage: object not found")
+
+// ErrInvalidKey is returned for keys that are empty or try to leave the storage root.
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// Storage stores opaque files under slash-separated keys such as "exports/<user>/<id>.zip".
+// LocalStorage is the only implementation today; an object store can be added behind the
+// same interface.
+type Storage interface {
+	// Put writes r to key, replacing any existing object, and returns the bytes written.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Open returns a reader for key; the caller closes it.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes key.
+	Delete(ctx context.Context, key string) error
+}
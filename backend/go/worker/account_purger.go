@@ -0,0 +1,51 @@
+/* Place: backend/go/worker/account_purger.go */
+package worker
+
+import (
+	"context"
+	"time"
+
+	"gatherup/models"
+	"gatherup/repository"
+)
+
+// AccountPurger finishes account deletions whose grace period has ended: the users row is
+// anonymized, content soft-deleted and credentials removed (see UserRepo.PurgeUser).
+type AccountPurger struct {
+	users *repository.UserRepo
+	audit *repository.AuditRepo
+	batch int
+}
+
+func NewAccountPurger(users *repository.UserRepo, audit *repository.AuditRepo, batch int) *AccountPurger {
+	return &AccountPurger{users: users, audit: audit, batch: batch}
+}
+
+func (p *AccountPurger) Name() string { return "account_purger" }
+
+// RunOnce purges up to one batch of due accounts.
+func (p *AccountPurger) RunOnce(ctx context.Context) (int, error) {
+	ids, err := p.users.ListDueDeletions(ctx, time.Now().UTC(), p.batch)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, id := range ids {
+		ok, err := p.users.PurgeUser(ctx, id)
+		if err != nil {
+			return n, err
+		}
+		if !ok {
+			// cancelled between listing and purging
+			continue
+		}
+		n++
+		// best-effort: failures are logged by the repo
+		_ = p.audit.Insert(ctx, &models.AuditLog{
+			EntityType: "user",
+			EntityID:   id,
+			Action:     "account_purged",
+		})
+	}
+	return n, nil
+}
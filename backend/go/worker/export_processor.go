@@ -0,0 +1,92 @@
+/* Place: backend/go/worker/export_processor.go */
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"gatherup/repository"
+	"gatherup/service"
+)
+
+// exportLease is how long a claimed export job is reserved; building an archive for even a
+// very active account takes well under this.
+const exportLease = 10 * time.Minute
+
+// ExportProcessor builds queued data exports and deletes expired ones.
+type ExportProcessor struct {
+	jobs     *repository.JobRepo
+	exports  *service.ExportService
+	batch    int
+	workerID string
+}
+
+func NewExportProcessor(jobs *repository.JobRepo, exports *service.ExportService, batch int) *ExportProcessor {
+	host, _ := os.Hostname()
+	return &ExportProcessor{jobs: jobs, exports: exports, batch: batch, workerID: fmt.Sprintf("%s:%d", host, os.Getpid())}
+}
+
+func (p *ExportProcessor) Name() string { return "export_processor" }
+
+// RunOnce fails exports whose last attempt died with its worker, handles up to one batch of
+// export jobs, then removes expired archives.
+func (p *ExportProcessor) RunOnce(ctx context.Context) (int, error) {
+	exhausted, err := p.jobs.FailExhausted(ctx, repository.JobTopicUserExport)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, j := range exhausted {
+		var payload repository.ExportJobPayload
+		if json.Unmarshal([]byte(j.Payload), &payload) != nil {
+			continue
+		}
+		if err := p.exports.FailExport(ctx, payload.ExportID); err != nil {
+			return n, err
+		}
+		n++
+	}
+
+	for claimed := 0; claimed < p.batch; claimed++ {
+		j, err := p.jobs.Claim(ctx, repository.JobTopicUserExport, p.workerID, exportLease)
+		if err != nil {
+			return n, err
+		}
+		if j == nil {
+			break
+		}
+		n++
+
+		var payload repository.ExportJobPayload
+		if err := json.Unmarshal([]byte(j.Payload), &payload); err != nil {
+			// malformed payloads never succeed; burn the remaining attempts
+			j.Attempts = j.MaxAttempts
+			if _, err := p.jobs.Fail(ctx, j, "invalid payload: "+err.Error(), time.Now().UTC()); err != nil {
+				return n, err
+			}
+			continue
+		}
+		if err := p.exports.BuildExport(ctx, payload.ExportID); err != nil {
+			// back off a little more on every attempt
+			retry, ferr := p.jobs.Fail(ctx, j, err.Error(), time.Now().UTC().Add(time.Duration(j.Attempts)*time.Minute))
+			if ferr != nil {
+				return n, ferr
+			}
+			if !retry {
+				if err := p.exports.FailExport(ctx, payload.ExportID); err != nil {
+					return n, err
+				}
+			}
+			continue
+		}
+		if err := p.jobs.Complete(ctx, j.ID); err != nil {
+			return n, err
+		}
+	}
+
+	purged, err := p.exports.PurgeExpired(ctx, p.batch)
+	return n + purged, err
+}
@@ -0,0 +1,26 @@
+/* Place: backend/go/worker/mobile_lookup_indexer.go */
+package worker
+
+import (
+	"context"
+
+	"gatherup/service"
+)
+
+// MobileLookupIndexer hashes newly verified mobile numbers so phone-book discovery can find
+// them (see DiscoveryService.IndexMobileLookups).
+type MobileLookupIndexer struct {
+	discovery *service.DiscoveryService
+	batch     int
+}
+
+func NewMobileLookupIndexer(discovery *service.DiscoveryService, batch int) *MobileLookupIndexer {
+	return &MobileLookupIndexer{discovery: discovery, batch: batch}
+}
+
+func (x *MobileLookupIndexer) Name() string { return "mobile_lookup_indexer" }
+
+// RunOnce indexes up to one batch of numbers.
+func (x *MobileLookupIndexer) RunOnce(ctx context.Context) (int, error) {
+	return x.discovery.IndexMobileLookups(ctx, x.batch)
+}
@@ -0,0 +1,36 @@
+/* Place: backend/go/worker/runner.go */
+package worker
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Task is one unit of periodic background work. RunOnce returns how many items it handled.
+type Task interface {
+	Name() string
+	RunOnce(ctx context.Context) (int, error)
+}
+
+// Run calls every task once per interval until ctx is cancelled. A failing task is logged and
+// retried on the next tick; it never stops the others.
+func Run(ctx context.Context, interval time.Duration, logger *log.Logger, tasks ...Task) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		for _, t := range tasks {
+			n, err := t.RunOnce(ctx)
+			if err != nil {
+				logger.Printf("worker: %s failed after %d items: %v", t.Name(), n, err)
+			} else if n > 0 {
+				logger.Printf("worker: %s handled %d items", t.Name(), n)
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,28 @@
+-- migrations/0002_refresh_token_sessions.sql
+USE GatherUpDB;
+GO
+SET NOCOUNT ON;
+GO
+
+-- ======================================================================
+-- Notes:
+-- - Groups every refresh token issued from one login under a session_id.
+-- - Rotation copies session_id forward; access tokens carry it as the "sid" claim,
+--   so revoking a session also stops its already-issued access tokens.
+-- ======================================================================
+
+IF COL_LENGTH('dbo.refresh_tokens','session_id') IS NULL
+BEGIN
+  ALTER TABLE dbo.refresh_tokens ADD session_id UNIQUEIDENTIFIER NULL;
+END
+GO
+
+-- existing tokens become their own session
+UPDATE dbo.refresh_tokens SET session_id = id WHERE session_id IS NULL;
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_refresh_tokens_session' AND object_id = OBJECT_ID('dbo.refresh_tokens'))
+BEGIN
+  CREATE INDEX idx_refresh_tokens_session ON dbo.refresh_tokens(session_id, is_revoked);
+END
+GO
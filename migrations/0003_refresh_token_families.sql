@@ -0,0 +1,25 @@
+-- migrations/0003_refresh_token_families.sql
+USE GatherUpDB;
+GO
+SET NOCOUNT ON;
+GO
+
+-- ======================================================================
+-- Notes:
+-- - Refresh token families: session_id (0002) identifies the family, parent_id
+--   points at the token that was rotated into this one.
+-- - A revoked token that already has a child was rotated; presenting it again is
+--   treated as theft and revokes the whole family.
+-- ======================================================================
+
+IF COL_LENGTH('dbo.refresh_tokens','parent_id') IS NULL
+BEGIN
+  ALTER TABLE dbo.refresh_tokens ADD parent_id UNIQUEIDENTIFIER NULL;
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_refresh_tokens_parent' AND object_id = OBJECT_ID('dbo.refresh_tokens'))
+BEGIN
+  CREATE INDEX idx_refresh_tokens_parent ON dbo.refresh_tokens(parent_id) WHERE parent_id IS NOT NULL;
+END
+GO
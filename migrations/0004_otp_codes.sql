@@ -0,0 +1,33 @@
+-- migrations/0004_otp_codes.sql
+USE GatherUpDB;
+GO
+SET NOCOUNT ON;
+GO
+
+-- ======================================================================
+-- Notes:
+-- - One-time codes (mobile verification, later password reset / login).
+-- - Only an HMAC of the code is stored; attempts are counted per code.
+-- ======================================================================
+
+IF OBJECT_ID('dbo.otp_codes','U') IS NULL
+BEGIN
+  CREATE TABLE dbo.otp_codes (
+    id UNIQUEIDENTIFIER PRIMARY KEY DEFAULT NEWSEQUENTIALID(),
+    user_id UNIQUEIDENTIFIER NULL,
+    purpose NVARCHAR(50) NOT NULL,
+    channel NVARCHAR(20) NOT NULL DEFAULT 'sms',
+    destination NVARCHAR(320) NOT NULL,
+    code_hash NVARCHAR(256) NOT NULL,
+    attempts INT NOT NULL DEFAULT 0,
+    max_attempts INT NOT NULL DEFAULT 5,
+    expires_at DATETIMEOFFSET NOT NULL,
+    consumed_at DATETIMEOFFSET NULL,
+    created_at DATETIMEOFFSET NOT NULL DEFAULT SYSDATETIMEOFFSET(),
+    is_deleted BIT NOT NULL DEFAULT 0,
+    deleted_at DATETIMEOFFSET NULL,
+    CONSTRAINT fk_otp_user FOREIGN KEY (user_id) REFERENCES dbo.users(id) ON DELETE CASCADE
+  );
+  CREATE INDEX idx_otp_destination_purpose ON dbo.otp_codes(destination, purpose, created_at DESC);
+END
+GO
@@ -0,0 +1,29 @@
+-- migrations/0005_user_roles.sql
+USE GatherUpDB;
+GO
+SET NOCOUNT ON;
+GO
+
+-- ======================================================================
+-- Notes:
+-- - Role assignments per user; every account implicitly has role 'user'.
+-- - Roles are copied into access tokens (Claims.Roles) at login/refresh.
+-- ======================================================================
+
+IF OBJECT_ID('dbo.user_roles','U') IS NULL
+BEGIN
+  CREATE TABLE dbo.user_roles (
+    id BIGINT IDENTITY(1,1) PRIMARY KEY,
+    user_id UNIQUEIDENTIFIER NOT NULL,
+    role NVARCHAR(50) NOT NULL,
+    granted_by UNIQUEIDENTIFIER NULL,
+    created_at DATETIMEOFFSET NOT NULL DEFAULT SYSDATETIMEOFFSET(),
+    is_deleted BIT NOT NULL DEFAULT 0,
+    deleted_at DATETIMEOFFSET NULL,
+    CONSTRAINT fk_userroles_user FOREIGN KEY (user_id) REFERENCES dbo.users(id) ON DELETE CASCADE,
+    CONSTRAINT fk_userroles_granted_by FOREIGN KEY (granted_by) REFERENCES dbo.users(id) ON DELETE NO ACTION,
+    CONSTRAINT ck_userroles_role CHECK (role IN ('admin','moderator','tournament_organizer','user'))
+  );
+  CREATE UNIQUE INDEX ux_user_roles_user_role ON dbo.user_roles(user_id, role) WHERE is_deleted = 0;
+END
+GO
@@ -0,0 +1,58 @@
+-- migrations/0006_api_keys.sql
+USE GatherUpDB;
+GO
+SET NOCOUNT ON;
+GO
+
+-- ======================================================================
+-- Notes:
+-- - Personal API keys for scripts and scoring apps; only the SHA-256 hash of a key is stored
+--   (same scheme as refresh_tokens.token_hash). key_prefix is kept to tell keys apart in UIs.
+-- - scopes is a space-separated list, e.g. 'tournament:score:write profile:read'.
+-- - Bot accounts are users with is_bot = 1 owned by bot_owner_id; they have no credentials and
+--   authenticate only with API keys. mobile_number/mobile_normalized get a 'bot:' placeholder.
+-- ======================================================================
+
+IF COL_LENGTH('dbo.users','is_bot') IS NULL
+BEGIN
+  ALTER TABLE dbo.users ADD is_bot BIT NOT NULL CONSTRAINT df_users_is_bot DEFAULT 0;
+END
+GO
+
+IF COL_LENGTH('dbo.users','bot_owner_id') IS NULL
+BEGIN
+  ALTER TABLE dbo.users ADD bot_owner_id UNIQUEIDENTIFIER NULL
+    CONSTRAINT fk_users_bot_owner FOREIGN KEY REFERENCES dbo.users(id);
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_users_bot_owner' AND object_id = OBJECT_ID('dbo.users'))
+BEGIN
+  CREATE INDEX idx_users_bot_owner ON dbo.users(bot_owner_id) WHERE bot_owner_id IS NOT NULL;
+END
+GO
+
+IF OBJECT_ID('dbo.api_keys','U') IS NULL
+BEGIN
+  CREATE TABLE dbo.api_keys (
+    id UNIQUEIDENTIFIER PRIMARY KEY DEFAULT NEWSEQUENTIALID(),
+    user_id UNIQUEIDENTIFIER NOT NULL,
+    name NVARCHAR(100) NOT NULL,
+    key_prefix NVARCHAR(16) NOT NULL,
+    key_hash NVARCHAR(200) NOT NULL,
+    scopes NVARCHAR(1000) NOT NULL,
+    created_by UNIQUEIDENTIFIER NULL,
+    created_at DATETIMEOFFSET NOT NULL DEFAULT SYSDATETIMEOFFSET(),
+    expires_at DATETIMEOFFSET NOT NULL,
+    last_used_at DATETIMEOFFSET NULL,
+    is_revoked BIT NOT NULL DEFAULT 0,
+    revoked_at DATETIMEOFFSET NULL,
+    is_deleted BIT NOT NULL DEFAULT 0,
+    deleted_at DATETIMEOFFSET NULL,
+    CONSTRAINT fk_apikeys_user FOREIGN KEY (user_id) REFERENCES dbo.users(id) ON DELETE NO ACTION,
+    CONSTRAINT fk_apikeys_created_by FOREIGN KEY (created_by) REFERENCES dbo.users(id) ON DELETE NO ACTION
+  );
+  CREATE UNIQUE INDEX ux_api_keys_hash ON dbo.api_keys(key_hash);
+  CREATE INDEX idx_api_keys_user ON dbo.api_keys(user_id) WHERE is_revoked = 0 AND is_deleted = 0;
+END
+GO
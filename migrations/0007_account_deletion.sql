@@ -0,0 +1,32 @@
+-- migrations/0007_account_deletion.sql
+USE GatherUpDB;
+GO
+SET NOCOUNT ON;
+GO
+
+-- ======================================================================
+-- Notes:
+-- - DELETE /api/me sets deletion_requested_at / deletion_scheduled_at and is_active = 0;
+--   the account can be restored by logging in until deletion_scheduled_at.
+-- - After that the worker (cmd/worker) anonymizes the users row, soft-deletes the user's content
+--   and removes credentials; is_deleted/deleted_at on dbo.users mark the purge as done.
+-- ======================================================================
+
+IF COL_LENGTH('dbo.users','deletion_requested_at') IS NULL
+BEGIN
+  ALTER TABLE dbo.users ADD deletion_requested_at DATETIMEOFFSET NULL;
+END
+GO
+
+IF COL_LENGTH('dbo.users','deletion_scheduled_at') IS NULL
+BEGIN
+  ALTER TABLE dbo.users ADD deletion_scheduled_at DATETIMEOFFSET NULL;
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_users_deletion_scheduled' AND object_id = OBJECT_ID('dbo.users'))
+BEGIN
+  CREATE INDEX idx_users_deletion_scheduled ON dbo.users(deletion_scheduled_at)
+    WHERE deletion_scheduled_at IS NOT NULL AND is_deleted = 0;
+END
+GO
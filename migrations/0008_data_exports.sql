@@ -0,0 +1,43 @@
+-- migrations/0008_data_exports.sql
+USE GatherUpDB;
+GO
+SET NOCOUNT ON;
+GO
+
+-- ======================================================================
+-- Notes:
+-- - One row per "download my data" request. The work itself is a dbo.jobs row
+--   (topic 'user_export', payload {"export_id": ...}) picked up by cmd/worker.
+-- - storage_key points into the configured storage backend; files are deleted and the
+--   row soft-deleted once expires_at has passed.
+-- ======================================================================
+
+IF OBJECT_ID('dbo.data_exports','U') IS NULL
+BEGIN
+  CREATE TABLE dbo.data_exports (
+    id UNIQUEIDENTIFIER PRIMARY KEY DEFAULT NEWSEQUENTIALID(),
+    user_id UNIQUEIDENTIFIER NOT NULL,
+    status NVARCHAR(20) NOT NULL DEFAULT 'pending',
+    job_id BIGINT NULL,
+    storage_key NVARCHAR(500) NULL,
+    size_bytes BIGINT NULL,
+    error_message NVARCHAR(1000) NULL,
+    created_at DATETIMEOFFSET NOT NULL DEFAULT SYSDATETIMEOFFSET(),
+    completed_at DATETIMEOFFSET NULL,
+    expires_at DATETIMEOFFSET NULL,
+    is_deleted BIT NOT NULL DEFAULT 0,
+    deleted_at DATETIMEOFFSET NULL,
+    CONSTRAINT fk_dataexports_user FOREIGN KEY (user_id) REFERENCES dbo.users(id) ON DELETE NO ACTION,
+    CONSTRAINT fk_dataexports_job FOREIGN KEY (job_id) REFERENCES dbo.jobs(id) ON DELETE SET NULL,
+    CONSTRAINT ck_dataexports_status CHECK (status IN ('pending','running','completed','failed'))
+  );
+  CREATE INDEX idx_data_exports_user ON dbo.data_exports(user_id, created_at DESC);
+  CREATE INDEX idx_data_exports_expires ON dbo.data_exports(expires_at) WHERE is_deleted = 0 AND expires_at IS NOT NULL;
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_jobs_topic_status_runat' AND object_id = OBJECT_ID('dbo.jobs'))
+BEGIN
+  CREATE INDEX idx_jobs_topic_status_runat ON dbo.jobs(topic, status, run_at) WHERE is_deleted = 0;
+END
+GO
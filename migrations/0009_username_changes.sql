@@ -0,0 +1,18 @@
+-- migrations/0009_username_changes.sql
+USE GatherUpDB;
+GO
+SET NOCOUNT ON;
+GO
+
+-- ======================================================================
+-- Notes:
+-- - PATCH /api/me can claim or change users.username; ux_users_username keeps it unique.
+-- - username_changed_at records the last change so changes can be rate-limited. Claiming
+--   the first username (from NULL) also sets it.
+-- ======================================================================
+
+IF COL_LENGTH('dbo.users','username_changed_at') IS NULL
+BEGIN
+  ALTER TABLE dbo.users ADD username_changed_at DATETIMEOFFSET NULL;
+END
+GO
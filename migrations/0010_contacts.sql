@@ -0,0 +1,34 @@
+-- migrations/0010_contacts.sql
+USE GatherUpDB;
+GO
+SET NOCOUNT ON;
+GO
+
+-- ======================================================================
+-- Notes:
+-- - A contact request is one dbo.contacts row: user_id sent it to contact_user_id.
+--   status moves pending -> accepted (accepted_at set) or declined; cancelling, declining and
+--   unfriending soft-delete the row, and a later request revives it (the pair is unique).
+-- - dbo.user_contacts lists accepted contacts in both directions and is the only definition
+--   of "is a contact" (profiles, search, the 'contacts' visibility of posts and tournaments).
+-- ======================================================================
+
+IF NOT EXISTS (SELECT 1 FROM sys.check_constraints WHERE name = 'ck_contacts_status')
+BEGIN
+  ALTER TABLE dbo.contacts ADD CONSTRAINT ck_contacts_status CHECK (status IN ('pending','accepted','declined'));
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_contacts_contact_user' AND object_id = OBJECT_ID('dbo.contacts'))
+BEGIN
+  CREATE INDEX idx_contacts_contact_user ON dbo.contacts(contact_user_id, status) WHERE is_deleted = 0;
+END
+GO
+
+CREATE OR ALTER VIEW dbo.user_contacts AS
+  SELECT user_id, contact_user_id, accepted_at
+  FROM dbo.contacts WHERE status = 'accepted' AND is_deleted = 0
+  UNION ALL
+  SELECT contact_user_id AS user_id, user_id AS contact_user_id, accepted_at
+  FROM dbo.contacts WHERE status = 'accepted' AND is_deleted = 0;
+GO
@@ -0,0 +1,28 @@
+-- migrations/0011_blocks.sql
+USE GatherUpDB;
+GO
+SET NOCOUNT ON;
+GO
+
+-- ======================================================================
+-- Notes:
+-- - A block is one dbo.blocks row: user_id blocked blocked_user_id. Unblocking soft-deletes
+--   the row and blocking again revives it (the pair is unique).
+-- - A block works both ways: neither user can reach the other. dbo.user_blocks lists every
+--   live block in both directions and is the only definition of "blocked" (profiles, search,
+--   contacts, messaging, posts, tournament invites, mentions).
+-- ======================================================================
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_blocks_blocked_user' AND object_id = OBJECT_ID('dbo.blocks'))
+BEGIN
+  CREATE INDEX idx_blocks_blocked_user ON dbo.blocks(blocked_user_id) WHERE is_deleted = 0;
+END
+GO
+
+CREATE OR ALTER VIEW dbo.user_blocks AS
+  SELECT user_id, blocked_user_id AS other_user_id
+  FROM dbo.blocks WHERE is_deleted = 0
+  UNION ALL
+  SELECT blocked_user_id AS user_id, user_id AS other_user_id
+  FROM dbo.blocks WHERE is_deleted = 0;
+GO
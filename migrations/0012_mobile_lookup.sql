@@ -0,0 +1,33 @@
+-- migrations/0012_mobile_lookup.sql
+USE GatherUpDB;
+GO
+SET NOCOUNT ON;
+GO
+
+-- ======================================================================
+-- Notes:
+-- - mobile_lookup_hash = SHA-256(deployment salt || mobile_normalized), the value the apps
+--   send for phone-book discovery. The worker fills it for verified numbers only.
+-- - After changing CONTACT_DISCOVERY_SALT run
+--     UPDATE dbo.users SET mobile_lookup_hash = NULL WHERE mobile_lookup_hash IS NOT NULL;
+--   and the worker rehashes every verified number with the new salt.
+-- ======================================================================
+
+IF COL_LENGTH('dbo.users','mobile_lookup_hash') IS NULL
+BEGIN
+  ALTER TABLE dbo.users ADD mobile_lookup_hash BINARY(32) NULL;
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_users_mobile_lookup' AND object_id = OBJECT_ID('dbo.users'))
+BEGIN
+  CREATE INDEX idx_users_mobile_lookup ON dbo.users(mobile_lookup_hash) WHERE mobile_lookup_hash IS NOT NULL;
+END
+GO
+
+IF NOT EXISTS (SELECT 1 FROM sys.indexes WHERE name = 'idx_users_mobile_lookup_pending' AND object_id = OBJECT_ID('dbo.users'))
+BEGIN
+  CREATE INDEX idx_users_mobile_lookup_pending ON dbo.users(id)
+    WHERE mobile_lookup_hash IS NULL AND is_mobile_verified = 1 AND is_deleted = 0;
+END
+GO